@@ -2,64 +2,249 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 
+	"github.com/maxgio92/linux-packages/internal/network"
 	"github.com/maxgio92/linux-packages/internal/output/log"
-	"github.com/maxgio92/linux-packages/pkg/distro/centos"
+	"github.com/maxgio92/linux-packages/pkg/config"
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/packages"
 )
 
 const (
-	ProgramName = "packages"
-	flagCentos  = "centos"
-	flagAll     = "--all"
+	ProgramName  = "packages"
+	flagLogLevel = "log-level"
+	flagConfig   = "config"
+	flagTimeout  = "timeout"
+
+	flagMaxRequests     = "max-requests"
+	flagMaxHostRequests = "max-host-requests"
+	flagHostRate        = "host-rate"
+	flagMaxAttempts     = "max-attempts"
+
+	flagStageConcurrency = "stage-concurrency"
+
+	defaultMaxRequests     = 64
+	defaultMaxHostRequests = network.DefaultHostConcurrency
+)
+
+const (
+	// ExitCodeFailure is the exit code of the commands that failed, or that completed
+	// partially, as some mirrors, repositories or databases could not be searched.
+	ExitCodeFailure = 1
+
+	// ExitCodeTimeout is the exit code of the commands stopped by their timeout,
+	// after the results found so far are written, as for timeout(1).
+	ExitCodeTimeout = 124
 )
 
 var (
-	LogLevel = logrus.DebugLevel
+	LogLevel = logrus.InfoLevel
+
+	// cfg is the configuration of the distros, which is empty when no file is specified.
+	cfg *config.Config
+
+	// timeout is the maximum duration of the commands, which is unlimited when zero.
+	timeout time.Duration
+
+	// maxRequests, maxHostRequests and hostRate limit the requests to the mirrors,
+	// overall and by host, and are unlimited when zero.
+	maxRequests     = defaultMaxRequests
+	maxHostRequests = defaultMaxHostRequests
+	hostRate        float64
+
+	// stageConcurrency is the maximum number of the mirrors, repositories or databases
+	// that each stage of the pipelines processes at once.
+	stageConcurrency = packages.DefaultStageConcurrency
+
+	// maxAttempts is the maximum number of attempts of each request to the mirrors.
+	maxAttempts = network.DefaultAttempts
 )
 
-// TODO: use Cobra.
+// Run runs the command line interface and exits on failure, with ExitCodeTimeout
+// when the timeout stops the command, and with ExitCodeFailure otherwise.
+// An interrupt or a termination signal stops the command, as its timeout does.
 func Run() {
-	if len(os.Args) < 3 {
-		fmt.Println("Please specify a distro and package name as arguments")
-		fmt.Printf("usage: %s distro|--all package-name\n", ProgramName)
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := NewRootCmd().ExecuteContext(ctx)
+	stop()
+
+	if err != nil {
+		os.Exit(ExitCode(err))
 	}
+}
 
-	switch os.Args[1] {
-	case flagCentos:
-		runCentos(os.Args[2])
-	case flagAll:
-		runCentos(os.Args[2])
+// ExitCode returns the exit code of the command that returned err.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitCodeTimeout
 	default:
-		fmt.Println("distro not supported")
-		os.Exit(1)
+		return ExitCodeFailure
+	}
+}
+
+// NewRootCmd returns the root command of the command line interface.
+func NewRootCmd() *cobra.Command {
+	logLevel := LogLevel.String()
+	configFile := ""
+
+	cmd := &cobra.Command{
+		Use:          ProgramName,
+		Short:        "Search packages in the repositories of Linux distributions",
+		SilenceUsage: true,
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			level, err := logrus.ParseLevel(logLevel)
+			if err != nil {
+				return err
+			}
+			LogLevel = level
+
+			if configFile == "" {
+				return nil
+			}
+			if cfg, err = config.LoadFile(configFile); err != nil {
+				return err
+			}
+			for name := range cfg.Distros {
+				if !contains(distro.Names(), name) {
+					return fmt.Errorf("distro %s of the configuration not supported", name)
+				}
+			}
+
+			return nil
+		},
 	}
+	cmd.PersistentFlags().StringVar(&logLevel, flagLogLevel, logLevel,
+		"level of the logs written to the standard error: panic, fatal, error, warn, info, debug or trace")
+	cmd.PersistentFlags().StringVar(&configFile, flagConfig, "",
+		"path of the YAML configuration file of the mirrors and the repositories of the distros")
+	cmd.PersistentFlags().DurationVar(&timeout, flagTimeout, 0,
+		"maximum duration of the search, after which the packages found so far are written and the command exits with 124, such as 30s or 5m")
+	cmd.PersistentFlags().IntVar(&maxRequests, flagMaxRequests, maxRequests,
+		"maximum number of concurrent requests to all the mirrors, unlimited when 0")
+	cmd.PersistentFlags().IntVar(&maxHostRequests, flagMaxHostRequests, maxHostRequests,
+		"maximum number of concurrent requests to each mirror, unlimited when 0")
+	cmd.PersistentFlags().Float64Var(&hostRate, flagHostRate, 0,
+		"maximum number of requests per second to each mirror, unlimited when 0")
+	cmd.PersistentFlags().IntVar(&stageConcurrency, flagStageConcurrency, stageConcurrency,
+		"maximum number of mirrors, repositories or databases processed at once by each stage of the search")
+	cmd.PersistentFlags().IntVar(&maxAttempts, flagMaxAttempts, maxAttempts,
+		"maximum number of attempts of each request to the mirrors, which are retried on timeouts, 429 and 5xx responses")
+
+	cmd.AddCommand(newSearchCmd())
+	cmd.AddCommand(newDownloadCmd())
+
+	return cmd
+}
+
+// withTimeout returns the context of the command, which is done after the timeout, if any.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
 }
 
-func runCentos(packageName string) {
-	logger := log.NewJSONLogger(
+func newLogger() *logrus.Logger {
+	return log.NewJSONLogger(
 		log.WithLevel(LogLevel),
 		log.WithOutput(os.Stderr),
 	)
+}
 
-	outLogger := log.NewJSONLogger(
-		log.WithOutput(os.Stdout),
-	)
+// withLimiter returns the context of the command, with which the requests to the mirrors
+// and the values processed at once by the stages of the pipelines are limited.
+func withLimiter(ctx context.Context) context.Context {
+	ctx = packages.WithStageConcurrency(ctx, stageConcurrency)
+
+	return packages.WithLimiter(ctx, packages.NewLimiter(
+		packages.WithLimiterConcurrency(maxRequests),
+		packages.WithLimiterHostConcurrency(maxHostRequests),
+		packages.WithLimiterHostRate(hostRate, 1),
+	))
+}
+
+// withClient returns the context of the command, with which the requests to the mirrors
+// are retried up to the maximum number of attempts.
+func withClient(ctx context.Context) context.Context {
+	return network.WithClient(ctx, network.NewClient(network.WithAttempts(maxAttempts)))
+}
+
+// withErrorCollector returns the context of the command, to which the stages
+// of the pipelines report their failures, and the collector of the failures.
+func withErrorCollector(ctx context.Context) (context.Context, *packages.ErrorCollector) {
+	c := packages.NewErrorCollector(packages.WithErrorCollectorLogger(newLogger()))
+
+	return packages.WithErrorCollector(ctx, c), c
+}
+
+// reportFailures writes the summary of the failures collected during the run
+// to the standard error, and returns an error when there are any, as the results
+// are partial.
+func reportFailures(c *packages.ErrorCollector) error {
+	summary := c.Summary()
+	if len(summary) == 0 {
+		return nil
+	}
+
+	total := 0
+	fmt.Fprintln(os.Stderr, "Failures by stage:")
+	for _, v := range summary {
+		total += v.Count
+		fmt.Fprintf(os.Stderr, "  %s: %d%s\n", v.Stage, v.Count, formatStatusCodes(v.StatusCodes))
+	}
 
-	for p := range centos.NewPackageSearch(
-		centos.WithPackageNames(packageName),
-		centos.WithDefaultRepos(true),
-		centos.WithSearchLogger(logger),
-	).Search(context.Background()) {
-		outLogger.
-			WithField("name", p.Describe()).
-			WithField("version", p.Version()).
-			WithField("architecture", p.Architecture()).
-			WithField("location", p.Locate()).
-			Info()
+	return fmt.Errorf("the run completed partially: %d sources failed", total)
+}
+
+// runError returns the error of a run of the pipelines that the sink ended with err:
+// the error of the sink, if any, the one of the context, when the run was stopped,
+// or the one of the failures collected, which are summarized anyway.
+func runError(ctx context.Context, err error, c *packages.ErrorCollector) error {
+	failuresErr := reportFailures(c)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("the run was stopped by the timeout, the results are partial: %w", err)
+	}
+	if err == nil {
+		err = failuresErr
 	}
+
+	return err
+}
+
+// formatStatusCodes returns the numbers of failures by status code, such as " (404: 2, 503: 10)".
+func formatStatusCodes(statusCodes map[int]int) string {
+	if len(statusCodes) == 0 {
+		return ""
+	}
+
+	codes := make([]int, 0, len(statusCodes))
+	for k := range statusCodes {
+		codes = append(codes, k)
+	}
+	sort.Ints(codes)
+
+	counts := make([]string, 0, len(codes))
+	for _, v := range codes {
+		counts = append(counts, fmt.Sprintf("%d: %d", v, statusCodes[v]))
+	}
+
+	return " (" + strings.Join(counts, ", ") + ")"
 }
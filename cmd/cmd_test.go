@@ -0,0 +1,64 @@
+//go:build all_tests || all_unit_tests || (unit_tests && cmd)
+
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/cmd"
+)
+
+var _ = Describe("Root command", func() {
+	DescribeTable("Exit codes",
+		func(err error, expected int) {
+			Expect(cmd.ExitCode(err)).To(Equal(expected))
+		},
+		Entry("without errors", nil, 0),
+		Entry("with a failure", errors.New("the run completed partially: 2 sources failed"), cmd.ExitCodeFailure),
+		Entry("with an interrupt", context.Canceled, cmd.ExitCodeFailure),
+		Entry("with the timeout", context.DeadlineExceeded, cmd.ExitCodeTimeout),
+		Entry("with the wrapped timeout", fmt.Errorf("search: %w", context.DeadlineExceeded), cmd.ExitCodeTimeout),
+	)
+
+	It("Should exit with the timeout exit code when the timeout stops the search", func() {
+		// The mirror never responds, so that the search is stopped by the timeout.
+		m := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		DeferCleanup(m.Close)
+
+		configFile := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(configFile, []byte(fmt.Sprintf(`
+distros:
+  centos:
+    mirrors:
+      - %s/centos/
+`, m.URL)), 0o644)).To(Succeed())
+
+		root := cmd.NewRootCmd()
+		root.SetArgs([]string{
+			"search", "kernel-devel",
+			"--distro", "centos",
+			"--config", configFile,
+			"--timeout", "200ms",
+			"--max-attempts", "1",
+			"--log-level", "panic",
+		})
+
+		start := time.Now()
+		err := root.ExecuteContext(context.Background())
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(cmd.ExitCode(err)).To(Equal(cmd.ExitCodeTimeout))
+	})
+})
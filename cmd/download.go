@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/maxgio92/linux-packages/pkg/distro/centos"
+	"github.com/maxgio92/linux-packages/pkg/distro/fedora"
+	"github.com/maxgio92/linux-packages/pkg/distro/opensuse"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+const (
+	flagDest        = "dest"
+	flagConcurrency = "concurrency"
+)
+
+// rpmDistros are the distros of which the packages can be downloaded,
+// as their repositories declare the checksums to verify the package files against.
+var rpmDistros = []string{centos.Name, fedora.Name, opensuse.Name}
+
+func newDownloadCmd() *cobra.Command {
+	o := newSearchOptions(rpmDistros...)
+	dest := "."
+	concurrency := rpm.DefaultDownloadConcurrency
+
+	cmd := &cobra.Command{
+		Use:   "download [package-name...]",
+		Short: "Download the packages found by name, by file path or by capability, verifying their checksums",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(args); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dest, 0o755); err != nil {
+				return err
+			}
+
+			ctx, cancel := withTimeout(cmd.Context())
+			defer cancel()
+			ctx, failures := withErrorCollector(withClient(withLimiter(ctx)))
+
+			pkgs, err := o.search(ctx, args)
+			if err != nil {
+				return err
+			}
+
+			d := rpm.NewDownloader(
+				rpm.WithDownloadDir(dest),
+				rpm.WithDownloadConcurrency(concurrency),
+				rpm.WithDownloadLogger(newLogger()),
+			)
+
+			// The failures are summarized even when the run is stopped, as the results are partial anyway.
+			return runError(ctx, d.Run(ctx, pkgs), failures)
+		},
+	}
+
+	o.addFlags(cmd)
+	cmd.Flags().StringVar(&dest, flagDest, dest, "directory the package files are written to")
+	cmd.Flags().IntVar(&concurrency, flagConcurrency, concurrency, "maximum number of packages downloaded in parallel")
+
+	return cmd
+}
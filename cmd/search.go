@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	// The distros register themselves on import.
+	_ "github.com/maxgio92/linux-packages/pkg/distro/alpine"
+	_ "github.com/maxgio92/linux-packages/pkg/distro/arch"
+	_ "github.com/maxgio92/linux-packages/pkg/distro/centos"
+	_ "github.com/maxgio92/linux-packages/pkg/distro/debian"
+	_ "github.com/maxgio92/linux-packages/pkg/distro/fedora"
+	_ "github.com/maxgio92/linux-packages/pkg/distro/opensuse"
+	_ "github.com/maxgio92/linux-packages/pkg/distro/ubuntu"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+const (
+	flagDistro         = "distro"
+	flagAll            = "all"
+	flagArch           = "arch"
+	flagRepoTemplate   = "repo-template"
+	flagAllRepos       = "all-repos"
+	flagMatch          = "match"
+	flagLatest         = "latest"
+	flagMergeMirrors   = "merge-mirrors"
+	flagVersion        = "version"
+	flagOutput         = "output"
+	flagFile           = "file"
+	flagProvides       = "provides"
+	flagRequires       = "requires"
+	flagSnapshots      = "snapshots"
+	flagSnapshotsSince = "snapshots-since"
+
+	// dateLayout is the layout of the dates of the flags.
+	dateLayout = "2006-01-02"
+)
+
+// searchOptions are the options of the search common to the distros.
+// The options that a distro does not support are ignored.
+type searchOptions struct {
+	// supported are the distros that can be searched, all of which are searched with --all.
+	supported     []string
+	distros       []string
+	all           bool
+	archs         []string
+	repoTemplates []string
+	allRepos      bool
+	mode          string
+	latest        bool
+	mergeMirrors  bool
+	versions      []string
+	filePaths     []string
+	provides      []string
+	requires      []string
+	snapshots     bool
+	since         string
+}
+
+func newSearchOptions(supported ...string) *searchOptions {
+	return &searchOptions{supported: supported}
+}
+
+func newSearchCmd() *cobra.Command {
+	o := newSearchOptions(distro.Names()...)
+	output := ""
+
+	cmd := &cobra.Command{
+		Use:   "search [package-name...]",
+		Short: "Search packages by name, by file path or by capability",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(args); err != nil {
+				return err
+			}
+			if !contains(formats(), output) {
+				return fmt.Errorf("output format %s not supported", output)
+			}
+
+			ctx, cancel := withTimeout(cmd.Context())
+			defer cancel()
+			ctx, failures := withErrorCollector(withClient(withLimiter(ctx)))
+
+			pkgs, err := o.search(ctx, args)
+			if err != nil {
+				return err
+			}
+
+			w := packages.NewWriter(
+				packages.WithWriterFormat(packages.Format(output)),
+				packages.WithWriterOutput(os.Stdout),
+				packages.WithWriterLogger(newLogger()),
+			)
+
+			// The failures are summarized even when the run is stopped, as the results are partial anyway.
+			return runError(ctx, w.Run(ctx, pkgs), failures)
+		},
+	}
+
+	o.addFlags(cmd)
+	cmd.Flags().StringVarP(&output, flagOutput, "o", string(packages.FormatNDJSON),
+		"format of the results written to the standard output: "+strings.Join(formats(), ", "))
+
+	return cmd
+}
+
+func (o *searchOptions) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringSliceVar(&o.distros, flagDistro, nil,
+		"distros to search the packages in: "+strings.Join(o.supported, ", "))
+	flags.BoolVar(&o.all, flagAll, false, "search the packages in all the supported distros")
+	flags.StringSliceVar(&o.archs, flagArch, nil,
+		"architectures of the repositories to search the packages in (all distros but opensuse)")
+	flags.StringSliceVar(&o.repoTemplates, flagRepoTemplate, nil,
+		"templates of the paths of the repositories, relative to the release versions, with the {{ .arch }} variable"+
+			" (centos, fedora, opensuse, arch and alpine)")
+	flags.BoolVar(&o.allRepos, flagAllRepos, false,
+		"search the packages in all the repositories found under the release versions (centos, fedora and opensuse)")
+	flags.StringVar(&o.mode, flagMatch, string(packages.MatchExact),
+		"how the package names are matched: exact, glob or regex")
+	flags.BoolVar(&o.latest, flagLatest, false,
+		"return only the newest build of each package per architecture and repository (centos, fedora and opensuse)")
+	flags.BoolVar(&o.mergeMirrors, flagMergeMirrors, true,
+		"merge the packages found through more mirrors in a single result with the mirrors as alternative locations,"+
+			" written when the search completes, or write them as soon as they are found when false (centos, fedora and opensuse)")
+	flags.StringSliceVar(&o.versions, flagVersion, nil,
+		"version constraints that the packages must all satisfy, such as \">= 5.14,< 6\" (centos, fedora and opensuse)")
+	flags.StringSliceVar(&o.filePaths, flagFile, nil,
+		"paths of the files, that can be glob patterns, shipped by the packages to search for,"+
+			" instead of the package names (centos, fedora and opensuse)")
+	flags.StringSliceVar(&o.provides, flagProvides, nil,
+		"capabilities provided by the packages to search for, such as \"kernel-devel-uname-r = 5.14.0\","+
+			" instead of the package names (centos, fedora and opensuse)")
+	flags.StringSliceVar(&o.requires, flagRequires, nil,
+		"capabilities required by the packages to search for, instead of the package names (centos, fedora and opensuse)")
+	flags.BoolVar(&o.snapshots, flagSnapshots, false,
+		"search the packages in the dated snapshots of the archives too, which are many (arch)")
+	flags.StringVar(&o.since, flagSnapshotsSince, "",
+		"search only the snapshots not older than the date, such as 2023-01-31, which enables --"+flagSnapshots+" (arch)")
+	cmd.MarkFlagsMutuallyExclusive(flagDistro, flagAll)
+}
+
+func (o *searchOptions) validate(names []string) error {
+	if !o.all && len(o.distros) == 0 {
+		return fmt.Errorf("either --%s or --%s must be specified", flagDistro, flagAll)
+	}
+	if len(names) == 0 && len(o.filePaths) == 0 && len(o.provides) == 0 && len(o.requires) == 0 {
+		return fmt.Errorf("either package names or any of --%s, --%s and --%s must be specified",
+			flagFile, flagProvides, flagRequires)
+	}
+	for _, v := range o.filePaths {
+		if _, err := path.Match(v, ""); err != nil {
+			return errors.Wrap(err, v)
+		}
+	}
+	for _, v := range append(append([]string{}, o.provides...), o.requires...) {
+		if _, err := rpm.ParseCapability(v); err != nil {
+			return err
+		}
+	}
+	for _, v := range o.distros {
+		if !contains(o.supported, v) {
+			return fmt.Errorf("distro %s not supported", v)
+		}
+	}
+	if _, err := packages.NewNameMatcher(packages.MatchMode(o.mode), names...); err != nil {
+		return err
+	}
+	for _, v := range o.versions {
+		if _, err := rpm.ParseConstraint(v); err != nil {
+			return err
+		}
+	}
+	if _, err := o.snapshotsSince(); err != nil {
+		return fmt.Errorf("--%s must be a date such as 2023-01-31: %w", flagSnapshotsSince, err)
+	}
+
+	return nil
+}
+
+// search returns the packages found in the distros.
+func (o *searchOptions) search(ctx context.Context, names []string) (chan *packages.Package, error) {
+	distros := o.distros
+	if o.all {
+		distros = o.supported
+	}
+
+	ds := make([]distro.Distro, 0, len(distros))
+	for _, v := range distros {
+		d, err := distro.New(v, o.distroOptions(v, names)...)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, d)
+	}
+
+	return distro.SearchAll(ctx, ds, packages.WithLogger(newLogger())), nil
+}
+
+// distroOptions returns the options of the search in the distro.
+// The architectures and the repository templates of the flags take precedence over
+// the ones of the configuration, which in turn take precedence over the distro defaults.
+func (o *searchOptions) distroOptions(name string, names []string) []distro.Option {
+	c := cfg.Distro(name)
+	archs, repos := o.archs, o.repoTemplates
+	if len(archs) == 0 {
+		archs = c.Archs
+	}
+	if len(repos) == 0 {
+		repos = c.Repos
+	}
+
+	// The date has been validated already.
+	since, _ := o.snapshotsSince()
+
+	return []distro.Option{
+		distro.WithNames(names...),
+		distro.WithMatchMode(packages.MatchMode(o.mode)),
+		distro.WithFilePaths(o.filePaths...),
+		distro.WithProvides(o.provides...),
+		distro.WithRequires(o.requires...),
+		distro.WithMirrors(c.Mirrors...),
+		distro.WithVersionRegex(c.VersionRegex),
+		distro.WithRepos(repos...),
+		distro.WithRepoVariables(c.Variables),
+		distro.WithArchs(archs...),
+		distro.WithAllRepos(o.allRepos),
+		distro.WithVersions(o.versions...),
+		distro.WithLatest(o.latest),
+		distro.WithStreamMirrors(!o.mergeMirrors),
+		distro.WithSnapshots(o.snapshots || !since.IsZero()),
+		distro.WithSnapshotsSince(since),
+		distro.WithLogger(newLogger()),
+	}
+}
+
+// snapshotsSince returns the date of the oldest snapshot to search, which is zero when not set.
+func (o *searchOptions) snapshotsSince() (time.Time, error) {
+	if o.since == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(dateLayout, o.since)
+}
+
+func formats() []string {
+	formats := make([]string, 0, len(packages.Formats))
+	for _, v := range packages.Formats {
+		formats = append(formats, string(v))
+	}
+
+	return formats
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
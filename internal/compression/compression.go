@@ -0,0 +1,109 @@
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	ExtGzip  = ".gz"
+	ExtXz    = ".xz"
+	ExtZstd  = ".zst"
+	ExtBzip2 = ".bz2"
+)
+
+var (
+	// Extensions are the file name extensions of the supported compression formats.
+	Extensions = []string{ExtGzip, ExtXz, ExtZstd, ExtBzip2}
+
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicBzip2 = []byte{'B', 'Z', 'h'}
+	magicXz    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// NewReader returns a reader of the decompressed content of r,
+// by choosing the decompressor from the extension of the file name.
+// When the extension is not of a known compression format, the decompressor
+// is chosen from the magic bytes at the beginning of the content, if any.
+// Otherwise, the content is returned as it is.
+func NewReader(r io.Reader, name string) (io.ReadCloser, error) {
+	switch path.Ext(name) {
+	case ExtGzip:
+		return newGzipReader(r)
+	case ExtXz:
+		return newXzReader(r)
+	case ExtZstd:
+		return newZstdReader(r)
+	case ExtBzip2:
+		return newBzip2Reader(r)
+	default:
+		return newSniffedReader(r)
+	}
+}
+
+// TrimExt returns the file name without the extension of the compression format, if any.
+func TrimExt(name string) string {
+	for _, ext := range Extensions {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+
+	return name
+}
+
+func newSniffedReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	// Peek returns an error along with fewer bytes for short contents,
+	// in which case the content is matched against what has been read.
+	magic, _ := br.Peek(len(magicXz))
+
+	switch {
+	case bytes.HasPrefix(magic, magicGzip):
+		return newGzipReader(br)
+	case bytes.HasPrefix(magic, magicXz):
+		return newXzReader(br)
+	case bytes.HasPrefix(magic, magicZstd):
+		return newZstdReader(br)
+	case bytes.HasPrefix(magic, magicBzip2):
+		return newBzip2Reader(br)
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+func newGzipReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func newXzReader(r io.Reader) (io.ReadCloser, error) {
+	xr, err := xz.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(xr), nil
+}
+
+func newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return zr.IOReadCloser(), nil
+}
+
+func newBzip2Reader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(bzip2.NewReader(r)), nil
+}
@@ -0,0 +1,222 @@
+package network
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	DefaultAttempts        = 5
+	DefaultInitialInterval = 2 * time.Second
+	DefaultMaxInterval     = 10 * time.Second
+
+	// backOffMultiplier is the factor by which the interval grows at each attempt.
+	backOffMultiplier = 2
+	// backOffJitter is the fraction of the interval by which it is randomized,
+	// so that the retries of concurrent requests are spread.
+	backOffJitter = 0.5
+)
+
+// DefaultClient is the client of the requests of which the context has no client.
+var DefaultClient = NewClient()
+
+// Client is an HTTP client that retries the requests that failed because of timeouts,
+// connection resets, server errors (5xx) or rate limiting (429), with an exponential
+// back-off with jitter. The Retry-After header of the responses is honored,
+// up to the maximum interval of the back-off.
+type Client struct {
+	client          *http.Client
+	attempts        int
+	initialInterval time.Duration
+	maxInterval     time.Duration
+}
+
+type ClientOption func(c *Client)
+
+// WithHTTPClient sets the HTTP client that sends the requests.
+// It defaults to a client with the LimitedClientTransport.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithAttempts sets the maximum number of attempts of each request, including the first one.
+func WithAttempts(attempts int) ClientOption {
+	return func(c *Client) {
+		c.attempts = attempts
+	}
+}
+
+// WithBackOff sets the interval before the first retry, which is doubled at each retry
+// up to maxInterval.
+func WithBackOff(initialInterval time.Duration, maxInterval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.initialInterval = initialInterval
+		c.maxInterval = maxInterval
+	}
+}
+
+func NewClient(o ...ClientOption) *Client {
+	c := &Client{
+		client:          &http.Client{Transport: LimitedClientTransport},
+		attempts:        DefaultAttempts,
+		initialInterval: DefaultInitialInterval,
+		maxInterval:     DefaultMaxInterval,
+	}
+	for _, f := range o {
+		f(c)
+	}
+	if c.attempts < 1 {
+		c.attempts = 1
+	}
+
+	return c
+}
+
+// Get sends a GET request to the URL, retrying it on transient failures, and returns
+// the response when its status is 200 OK.
+// The caller is responsible for closing the body of the returned response.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	var err error
+	for attempt := 0; attempt < c.attempts; attempt++ {
+		var (
+			resp       *http.Response
+			retryAfter time.Duration
+		)
+
+		resp, err = c.get(ctx, url)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || !isTransient(err) {
+				return nil, err
+			}
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		default:
+			// The body is drained so that the connection can be reused.
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			err = &StatusError{URL: url, StatusCode: resp.StatusCode}
+			if !isRetryableStatus(resp.StatusCode) {
+				return nil, err
+			}
+			// The servers are not waited for longer than the back-off.
+			retryAfter = minDuration(parseRetryAfter(resp.Header.Get("Retry-After")), c.maxInterval)
+		}
+
+		if attempt == c.attempts-1 {
+			break
+		}
+		if err := sleep(ctx, maxDuration(c.backOff(attempt), retryAfter)); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.client.Do(req)
+}
+
+// backOff returns the randomized interval before the retry that follows the attempt.
+func (c *Client) backOff(attempt int) time.Duration {
+	interval := float64(c.initialInterval) * math.Pow(backOffMultiplier, float64(attempt))
+	if interval > float64(c.maxInterval) {
+		interval = float64(c.maxInterval)
+	}
+	delta := backOffJitter * interval
+
+	return time.Duration(interval - delta + rand.Float64()*2*delta)
+}
+
+type clientKey struct{}
+
+// WithClient returns a copy of the context with which the requests are sent by the client.
+func WithClient(ctx context.Context, c *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+func clientFrom(ctx context.Context) *Client {
+	if c, ok := ctx.Value(clientKey{}).(*Client); ok {
+		return c
+	}
+
+	return DefaultClient
+}
+
+// isTransient returns whether the request failed because of a timeout or of
+// a connection reset, which are worth a retry.
+func isTransient(err error) bool {
+	if errors.Is(err, unix.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter returns the duration of the Retry-After header, which is either
+// a number of seconds or a date, and zero when missing or malformed.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+
+	return b
+}
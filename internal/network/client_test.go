@@ -0,0 +1,177 @@
+//go:build all_tests || all_unit_tests || (unit_tests && client)
+
+package network_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    = context.Background()
+		client = network.NewClient(
+			network.WithAttempts(3),
+			network.WithBackOff(time.Millisecond, 5*time.Millisecond),
+		)
+	)
+
+	// runMockServer runs a server that responds with the status codes in order,
+	// and with 200 OK once they are over, and counts the requests.
+	runMockServer := func(requests *int32, header http.Header, statusCodes ...int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			n := int(atomic.AddInt32(requests, 1))
+			if n <= len(statusCodes) {
+				for k, v := range header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(statusCodes[n-1])
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+	}
+
+	DescribeTable("With transient failures",
+		func(statusCodes []int, expectedRequests int32) {
+			var requests int32
+			m := runMockServer(&requests, nil, statusCodes...)
+			defer m.Close()
+
+			resp, err := client.Get(ctx, m.URL)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("ok"))
+			Expect(requests).To(Equal(expectedRequests))
+		},
+		Entry("without failures", []int{}, int32(1)),
+		Entry("with server errors", []int{http.StatusServiceUnavailable, http.StatusBadGateway}, int32(3)),
+		Entry("with rate limiting", []int{http.StatusTooManyRequests}, int32(2)),
+	)
+
+	Context("With too many transient failures", func() {
+		It("Should fail with the last status after the attempts", func() {
+			var requests int32
+			m := runMockServer(&requests, nil,
+				http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+			defer m.Close()
+
+			_, err := client.Get(ctx, m.URL)
+			Expect(err).To(Equal(&network.StatusError{URL: m.URL, StatusCode: http.StatusServiceUnavailable}))
+			Expect(requests).To(Equal(int32(3)))
+		})
+	})
+
+	Context("With a client failure", func() {
+		It("Should not retry", func() {
+			var requests int32
+			m := runMockServer(&requests, nil, http.StatusNotFound)
+			defer m.Close()
+
+			_, err := client.Get(ctx, m.URL)
+			Expect(err).To(Equal(&network.StatusError{URL: m.URL, StatusCode: http.StatusNotFound}))
+			Expect(requests).To(Equal(int32(1)))
+		})
+	})
+
+	Context("With a Retry-After header", func() {
+		It("Should wait for it before retrying", func() {
+			var requests int32
+			m := runMockServer(&requests, http.Header{"Retry-After": []string{"1"}}, http.StatusTooManyRequests)
+			defer m.Close()
+
+			client := network.NewClient(
+				network.WithAttempts(2),
+				network.WithBackOff(time.Millisecond, 2*time.Second),
+			)
+
+			start := time.Now()
+			resp, err := client.Get(ctx, m.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+			Expect(requests).To(Equal(int32(2)))
+		})
+		It("Should not wait for longer than the maximum back-off", func() {
+			var requests int32
+			m := runMockServer(&requests, http.Header{"Retry-After": []string{"60"}}, http.StatusTooManyRequests)
+			defer m.Close()
+
+			start := time.Now()
+			resp, err := client.Get(ctx, m.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(requests).To(Equal(int32(2)))
+		})
+	})
+
+	Context("With a timeout", func() {
+		It("Should retry", func() {
+			var requests int32
+			m := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					time.Sleep(100 * time.Millisecond)
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer m.Close()
+
+			client := network.NewClient(
+				network.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}),
+				network.WithAttempts(2),
+				network.WithBackOff(time.Millisecond, time.Millisecond),
+			)
+
+			resp, err := client.Get(ctx, m.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(requests).To(Equal(int32(2)))
+		})
+	})
+
+	Context("With a cancelled context", func() {
+		It("Should stop retrying", func() {
+			var requests int32
+			m := runMockServer(&requests, http.Header{"Retry-After": []string{"60"}}, http.StatusServiceUnavailable)
+			defer m.Close()
+
+			client := network.NewClient(network.WithBackOff(time.Minute, time.Minute))
+
+			ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+
+			_, err := client.Get(ctx, m.URL)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(requests).To(Equal(int32(1)))
+		})
+	})
+
+	Context("With the client of the context", func() {
+		It("Should send the requests with it", func() {
+			var requests int32
+			m := runMockServer(&requests, nil, http.StatusServiceUnavailable)
+			defer m.Close()
+
+			ctx := network.WithClient(ctx, network.NewClient(network.WithAttempts(1)))
+
+			_, err := network.Get(ctx, m.URL)
+			Expect(err).To(HaveOccurred())
+			Expect(requests).To(Equal(int32(1)))
+		})
+	})
+})
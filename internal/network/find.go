@@ -0,0 +1,37 @@
+package network
+
+import (
+	"context"
+
+	wfind "github.com/maxgio92/wfind/pkg/find"
+)
+
+type findResult struct {
+	found *wfind.Result
+	err   error
+}
+
+// Find runs the finder until it completes or the context is done.
+// The requests of the finder are limited by the limiter of the context only when
+// the finder sends them with the FindTransport of the context.
+// As the finder does not support cancellation, when the context is done first
+// the crawl is left in the background, where the requests sent with the FindTransport
+// of the context fail, and its result is discarded.
+func Find(ctx context.Context, finder *wfind.Options) (*wfind.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resCh := make(chan findResult, 1)
+	go func() {
+		found, err := finder.Find()
+		resCh <- findResult{found: found, err: err}
+	}()
+
+	select {
+	case res := <-resCh:
+		return res.found, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
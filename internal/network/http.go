@@ -1,19 +1,54 @@
 package network
 
 import (
+	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"time"
 )
 
-var c = http.Client{}
+// DefaultHostConcurrency is the default maximum number of concurrent requests to each host,
+// as many as the idle connections kept to each host.
+const DefaultHostConcurrency = 8
+
+// DefaultClientTransport is the transport shared by the clients and the finders,
+// so that they share the connections.
 var DefaultClientTransport = &http.Transport{
 	DialContext: (&net.Dialer{
 		Timeout:   60 * time.Second,
 		KeepAlive: 30 * time.Second,
 	}).DialContext,
 	MaxIdleConns:        1000,
-	MaxIdleConnsPerHost: 1000,
+	MaxIdleConnsPerHost: DefaultHostConcurrency,
 	IdleConnTimeout:     120 * time.Second,
 	TLSHandshakeTimeout: 30 * time.Second,
 }
+
+// LimitedClientTransport is the transport of the clients, which sends the requests
+// with the DefaultClientTransport, limited with the limiter of their context, if any.
+var LimitedClientTransport http.RoundTripper = &limitedTransport{next: DefaultClientTransport}
+
+// FindTransport returns the transport of the finders run with the context, which send
+// their requests without it. The requests are limited with the limiter of the context,
+// if any, one by one, and are canceled when the context is done.
+func FindTransport(ctx context.Context) http.RoundTripper {
+	return &limitedTransport{next: DefaultClientTransport, ctx: ctx}
+}
+
+// StatusError is the error of a response with an unexpected status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response from %s: %d", e.URL, e.StatusCode)
+}
+
+// Get sends a GET request to the URL with the client of the context, or the DefaultClient,
+// and returns the response when its status is 200 OK.
+// The caller is responsible for closing the body of the returned response.
+func Get(ctx context.Context, url string) (*http.Response, error) {
+	return clientFrom(ctx).Get(ctx, url)
+}
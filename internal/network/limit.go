@@ -0,0 +1,82 @@
+package network
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Limiter limits the requests sent to the hosts.
+type Limiter interface {
+	// Acquire waits until a request can be sent to the host, or the context is done,
+	// and returns the function that releases the request once completed.
+	Acquire(ctx context.Context, host string) (release func(), err error)
+}
+
+type limiterKey struct{}
+
+// WithLimiter returns a copy of the context with which the requests are limited by the limiter.
+func WithLimiter(ctx context.Context, l Limiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, l)
+}
+
+// acquire waits until a request can be sent to the host of rawURL, by the limiter
+// of the context, if any.
+func acquire(ctx context.Context, rawURL string) (func(), error) {
+	l, ok := ctx.Value(limiterKey{}).(Limiter)
+	if !ok {
+		return func() {}, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Acquire(ctx, u.Host)
+}
+
+// limitedTransport is a transport that sends the requests once allowed by the limiter
+// of their context. A request is released when the body of its response is closed,
+// so that the downloads in progress are limited too.
+type limitedTransport struct {
+	next http.RoundTripper
+
+	// ctx, when set, is the context of the requests, instead of their own.
+	ctx context.Context
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.ctx != nil {
+		req = req.WithContext(t.ctx)
+	}
+
+	release, err := acquire(req.Context(), req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
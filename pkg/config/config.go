@@ -0,0 +1,100 @@
+package config
+
+import (
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Config declares where and how the packages of the distros are searched,
+// overriding the defaults of the distros.
+type Config struct {
+	Distros map[string]*Distro `yaml:"distros"`
+}
+
+// Distro declares the mirrors and the repositories of a distro.
+// The unset fields fall back to the defaults of the distro, and the
+// fields that the distro does not support are ignored with a warning.
+type Distro struct {
+	// Mirrors are the URLs of the mirrors, used as seeds of the search.
+	Mirrors []string `yaml:"mirrors,omitempty"`
+
+	// VersionRegex is the regular expression that the names of the
+	// release version directories of the mirrors match.
+	VersionRegex string `yaml:"versionRegex,omitempty"`
+
+	// Repos are the templates of the paths of the repositories,
+	// relative to the release versions, with the {{ .arch }} variable.
+	Repos []string `yaml:"repos,omitempty"`
+
+	// Variables are the values of the variables of the repository templates,
+	// such as {{ .stream }}, which are expanded besides {{ .arch }}.
+	Variables map[string][]string `yaml:"variables,omitempty"`
+
+	// Archs are the architectures the repository templates are expanded with.
+	Archs []string `yaml:"archs,omitempty"`
+}
+
+// Load decodes and validates the YAML configuration read from r.
+func Load(r io.Reader) (*Config, error) {
+	c := new(Config)
+	if err := yaml.NewDecoder(r).Decode(c); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "error decoding the configuration")
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadFile decodes and validates the YAML configuration file at path.
+func LoadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Distro returns the configuration of the distro, which is empty when not declared.
+func (c *Config) Distro(name string) *Distro {
+	if c == nil || c.Distros[name] == nil {
+		return new(Distro)
+	}
+
+	return c.Distros[name]
+}
+
+// variableRegex matches the names of the variables of the repository templates.
+var variableRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+func (c *Config) validate() error {
+	for name, d := range c.Distros {
+		if d == nil {
+			continue
+		}
+		if d.VersionRegex != "" {
+			if _, err := regexp.Compile(d.VersionRegex); err != nil {
+				return errors.Wrapf(err, "invalid version regex of distro %s", name)
+			}
+		}
+		for k := range d.Variables {
+			if !variableRegex.MatchString(k) {
+				return errors.Errorf("invalid variable %q of distro %s", k, name)
+			}
+			// The architectures are declared by archs.
+			if k == "arch" {
+				return errors.Errorf("invalid variable %q of distro %s, declared by archs", k, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+//go:build all_tests || all_unit_tests || (unit_tests && config)
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/config"
+)
+
+const mockConfig = `
+distros:
+  centos:
+    mirrors:
+      - https://mirror.example.com/centos/
+    versionRegex: ^9-stream/?$
+    repos:
+      - /BaseOS/{{ .arch }}/os/repodata/repomd.xml
+      - /{{ .sig }}/{{ .arch }}/repodata/repomd.xml
+    variables:
+      sig:
+        - kmods
+    archs:
+      - x86_64
+  debian:
+    mirrors:
+      - https://mirror.example.com/debian/
+`
+
+var _ = Describe("Config", func() {
+	It("Should load the distros", func() {
+		c, err := config.Load(strings.NewReader(mockConfig))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(c.Distros).To(HaveLen(2))
+		Expect(c.Distro("centos")).To(Equal(&config.Distro{
+			Mirrors:      []string{"https://mirror.example.com/centos/"},
+			VersionRegex: "^9-stream/?$",
+			Repos: []string{
+				"/BaseOS/{{ .arch }}/os/repodata/repomd.xml",
+				"/{{ .sig }}/{{ .arch }}/repodata/repomd.xml",
+			},
+			Variables: map[string][]string{"sig": {"kmods"}},
+			Archs:     []string{"x86_64"},
+		}))
+		Expect(c.Distro("debian").Mirrors).To(Equal([]string{"https://mirror.example.com/debian/"}))
+	})
+
+	It("Should return an empty configuration of the distros not declared", func() {
+		c, err := config.Load(strings.NewReader(mockConfig))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(c.Distro("fedora")).To(Equal(&config.Distro{}))
+	})
+
+	It("Should load an empty file", func() {
+		c, err := config.Load(strings.NewReader(""))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Distro("centos")).To(Equal(&config.Distro{}))
+	})
+
+	It("Should load a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, []byte(mockConfig), 0o600)).To(Succeed())
+
+		c, err := config.LoadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Distros).To(HaveKey("centos"))
+	})
+
+	It("Should fail with a malformed version regex", func() {
+		_, err := config.Load(strings.NewReader("distros:\n  centos:\n    versionRegex: '[a-'\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail with a malformed variable name", func() {
+		_, err := config.Load(strings.NewReader("distros:\n  centos:\n    variables:\n      'a b': [x]\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail with the arch variable, declared by archs", func() {
+		_, err := config.Load(strings.NewReader("distros:\n  centos:\n    variables:\n      arch: [x86_64]\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail with malformed YAML", func() {
+		_, err := config.Load(strings.NewReader("distros: ["))
+		Expect(err).To(HaveOccurred())
+	})
+})
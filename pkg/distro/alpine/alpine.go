@@ -0,0 +1,142 @@
+package alpine
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/apk"
+	"github.com/maxgio92/linux-packages/pkg/template"
+)
+
+type PackageSearch struct {
+	names        []string
+	matchMode    packages.MatchMode
+	mirrors      []string
+	versionRegex string
+	repos        []string
+	variables    map[string][]string
+	archs        []string
+
+	logger *log.Logger
+}
+
+type PackageSearchOption func(s *PackageSearch)
+
+func WithPackageNames(names ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.names = names
+	}
+}
+
+// WithMatchMode sets how the package names are matched, that is
+// as exact names (default), glob patterns or RE2 regular expressions.
+func WithMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.matchMode = mode
+	}
+}
+
+// WithMirrors sets the mirrors to search the packages in, instead of DefaultMirrors.
+func WithMirrors(mirrors ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.mirrors = mirrors
+	}
+}
+
+// WithVersionRegex sets the regular expression that the names of the
+// release version directories of the mirrors match, instead of VersionRegex.
+func WithVersionRegex(re string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.versionRegex = re
+	}
+}
+
+func WithSearchLogger(logger *log.Logger) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.logger = logger
+	}
+}
+
+func WithRepoTemplates(repos ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.repos = repos
+	}
+}
+
+// WithRepoVariables sets the values of the variables of the repository templates,
+// which are expanded besides the {{ .arch }} one.
+func WithRepoVariables(vars map[string][]string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.variables = vars
+	}
+}
+
+func WithArchs(archs ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.archs = archs
+	}
+}
+
+func NewPackageSearch(o ...PackageSearchOption) *PackageSearch {
+	search := &PackageSearch{
+		mirrors:      DefaultMirrors,
+		versionRegex: VersionRegex,
+		repos:        DefaultReposT,
+		archs:        DefaultArchs,
+		logger:       log.New(),
+	}
+	for _, f := range o {
+		f(search)
+	}
+
+	return search
+}
+
+// Name returns the name of the distro.
+func (s *PackageSearch) Name() string {
+	return Name
+}
+
+// Seeds returns the URLs of the mirrors.
+func (s *PackageSearch) Seeds() []string {
+	return s.mirrors
+}
+
+// VersionStage returns the stage that searches the branches in the mirrors.
+func (s *PackageSearch) VersionStage() packages.StageRunner {
+	return distro.NewVersionSearcher(
+		distro.WithVersionSearcherName(Name),
+		distro.WithVersionSearcherRegex(s.versionRegex),
+		distro.WithVersionSearcherLogger(s.logger),
+	)
+}
+
+// RepoStage returns the stage that streams the index URLs of the branches.
+func (s *PackageSearch) RepoStage() packages.StageRunner {
+	return packages.StageFunc(func(ctx context.Context, data chan string) chan string {
+		t := template.NewMultiplexTemplate(
+			template.WithTemplates(s.repos...),
+			template.WithVariables(distro.RepoVariables(s.variables, s.archs)),
+		)
+		indexes, _ := t.Run()
+
+		return distro.StubStage(ctx, data, indexes)
+	})
+}
+
+// PackageStage returns the stage that searches the packages in the indexes.
+func (s *PackageSearch) PackageStage() packages.SearchStageRunner {
+	return apk.NewPackageSearcher(
+		apk.WithPackageNames(s.names...),
+		apk.WithPackageMatchMode(s.matchMode),
+		apk.WithPackageLogger(s.logger),
+	)
+}
+
+// Search is a data streaming pipeline.
+func (s *PackageSearch) Search(ctx context.Context) chan *packages.Package {
+	return distro.Search(ctx, s, packages.WithLogger(s.logger))
+}
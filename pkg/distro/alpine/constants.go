@@ -0,0 +1,26 @@
+package alpine
+
+const (
+	Name         = "alpine"
+	MirrorEdge   = "https://mirrors.edge.kernel.org/alpine/"
+	VersionRegex = `^(v[0-9]+\.[0-9]+|edge)\/?$`
+	X86_64       = "x86_64"
+	X86          = "x86"
+	Aarch64      = "aarch64"
+	Armv7        = "armv7"
+	Ppc64le      = "ppc64le"
+	S390x        = "s390x"
+)
+
+var (
+	// DefaultMirrors are the mirrors searched by default.
+	DefaultMirrors = []string{MirrorEdge}
+
+	DefaultReposT = []string{
+		"/main/{{ .arch }}/APKINDEX.tar.gz",
+		"/community/{{ .arch }}/APKINDEX.tar.gz",
+		// Only on the edge branch.
+		"/testing/{{ .arch }}/APKINDEX.tar.gz",
+	}
+	DefaultArchs = []string{X86_64, X86, Aarch64, Armv7, Ppc64le, S390x}
+)
@@ -0,0 +1,109 @@
+//go:build all_tests || all_unit_tests || (unit_tests && alpine && mirror)
+
+package alpine_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/distro/alpine"
+)
+
+const (
+	homedir = "alpine"
+)
+
+var (
+	branches    = []string{"v3.17", "v3.18", "edge"}
+	homedirBody = fmt.Sprintf(`
+<html>
+<head><title>Index of /%s/</title></head>
+<body>
+<h1>Index of /%s/</h1><hr><pre><a href="../">../</a>
+<a href="%s/">%s/</a>
+<a href="%s/">%s/</a>
+<a href="%s/">%s/</a>
+<a href="latest-stable/">latest-stable/</a>
+<a href="MIRRORS.txt">MIRRORS.txt</a>
+</pre><hr></body>
+</html>
+`, homedir,
+		homedir,
+		branches[0], branches[0],
+		branches[1], branches[1],
+		branches[2], branches[2])
+)
+
+func runMockMirror(t testing.TB) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+homedir+"/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(homedirBody))
+	})
+
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+var _ = Describe("Mirror branch search mock", func() {
+	var (
+		search *distro.VersionSearcher
+		ctx    = context.Background()
+	)
+
+	Context("With branches", func() {
+		BeforeEach(func() {
+			search = distro.NewVersionSearcher(distro.WithVersionSearcherRegex(alpine.VersionRegex))
+		})
+		Context("with seed URLs", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan string)
+				actual   []string
+				expected []string
+			)
+			BeforeAll(func() {
+
+				// Test producer.
+				go func() {
+					seed, _ := url.JoinPath(m.URL, homedir)
+					sourceCh <- seed
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+
+				// Expected data.
+				expected = []string{}
+				for _, v := range branches {
+					s, _ := url.JoinPath(m.URL, homedir, v+"/")
+					expected = append(expected, s)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stream results", func() {
+				Expect(actual).ToNot(BeEmpty())
+			})
+			It("Should stream only the branches", func() {
+				Expect(actual).To(ConsistOf(expected))
+			})
+		})
+	})
+})
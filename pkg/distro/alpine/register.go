@@ -0,0 +1,38 @@
+package alpine
+
+import (
+	"github.com/maxgio92/linux-packages/pkg/distro"
+)
+
+func init() {
+	distro.Register(Name, newDistro)
+}
+
+// newDistro returns the distro configured with the options common to the distros.
+// The unset options fall back to the defaults of the distro.
+func newDistro(o *distro.Options) distro.Distro {
+	opts := []PackageSearchOption{
+		WithPackageNames(o.Names...),
+		WithMatchMode(o.MatchMode),
+	}
+	if len(o.Mirrors) > 0 {
+		opts = append(opts, WithMirrors(o.Mirrors...))
+	}
+	if o.VersionRegex != "" {
+		opts = append(opts, WithVersionRegex(o.VersionRegex))
+	}
+	if len(o.Repos) > 0 {
+		opts = append(opts, WithRepoTemplates(o.Repos...))
+	}
+	if len(o.RepoVariables) > 0 {
+		opts = append(opts, WithRepoVariables(o.RepoVariables))
+	}
+	if len(o.Archs) > 0 {
+		opts = append(opts, WithArchs(o.Archs...))
+	}
+	if o.Logger != nil {
+		opts = append(opts, WithSearchLogger(o.Logger))
+	}
+
+	return NewPackageSearch(opts...)
+}
@@ -0,0 +1,164 @@
+package apt
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/deb"
+)
+
+// PackageSearch searches the packages of the distros with APT repositories, like Debian and Ubuntu,
+// which differ only in their mirrors, suite aliases, components and architectures.
+type PackageSearch struct {
+	name         string
+	names        []string
+	matchMode    packages.MatchMode
+	mirrors      []string
+	suiteAliases []string
+	components   []string
+	archs        []string
+
+	logger *log.Logger
+}
+
+type PackageSearchOption func(s *PackageSearch)
+
+// WithDistroName sets the name of the distro.
+func WithDistroName(name string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.name = name
+	}
+}
+
+func WithPackageNames(names ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.names = names
+	}
+}
+
+// WithMatchMode sets how the package names are matched, that is
+// as exact names (default), glob patterns or RE2 regular expressions.
+func WithMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.matchMode = mode
+	}
+}
+
+// WithMirrors sets the mirrors to search the packages in.
+func WithMirrors(mirrors ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.mirrors = mirrors
+	}
+}
+
+// WithSuiteAliases sets the suites that are symbolic links to the codenames,
+// which are not searched as they would duplicate the packages.
+func WithSuiteAliases(aliases ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.suiteAliases = aliases
+	}
+}
+
+func WithSearchLogger(logger *log.Logger) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.logger = logger
+	}
+}
+
+func WithComponents(components ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.components = components
+	}
+}
+
+func WithArchs(archs ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.archs = archs
+	}
+}
+
+func NewPackageSearch(o ...PackageSearchOption) *PackageSearch {
+	search := &PackageSearch{logger: log.New()}
+	for _, f := range o {
+		f(search)
+	}
+
+	return search
+}
+
+// Name returns the name of the distro.
+func (s *PackageSearch) Name() string {
+	return s.name
+}
+
+// Seeds returns the URLs of the mirrors.
+func (s *PackageSearch) Seeds() []string {
+	return s.mirrors
+}
+
+// VersionStage returns the stage that searches the suites in the mirrors.
+func (s *PackageSearch) VersionStage() packages.StageRunner {
+	return deb.NewSuiteSearcher(
+		deb.WithExcludedSuites(s.suiteAliases...),
+		deb.WithSuiteLogger(s.logger),
+	)
+}
+
+// RepoStage returns the stage that streams the package index URLs of the suites.
+// The indexes are listed by the Release file of each suite, or by the InRelease one
+// on the mirrors that serve only the latter.
+func (s *PackageSearch) RepoStage() packages.StageRunner {
+	return packages.StageFunc(func(ctx context.Context, data chan string) chan string {
+		data = distro.StubStage(ctx, data, []string{deb.FileRelease})
+
+		return deb.NewIndexSearcher(
+			deb.WithIndexComponents(s.components...),
+			deb.WithIndexArchs(s.archs...),
+			deb.WithIndexLogger(s.logger),
+		).Run(ctx, data)
+	})
+}
+
+// PackageStage returns the stage that searches the packages in the indexes.
+func (s *PackageSearch) PackageStage() packages.SearchStageRunner {
+	return deb.NewPackageSearcher(
+		deb.WithPackageNames(s.names...),
+		deb.WithPackageMatchMode(s.matchMode),
+		deb.WithPackageLogger(s.logger),
+	)
+}
+
+// Search is a data streaming pipeline.
+func (s *PackageSearch) Search(ctx context.Context) chan *packages.Package {
+	return distro.Search(ctx, s, packages.WithLogger(s.logger))
+}
+
+// Factory returns the factory of the distro with the default options, such as
+// its name and mirrors, which is configured with the options common to the distros.
+// The unset options fall back to the defaults.
+func Factory(defaults ...PackageSearchOption) distro.Factory {
+	return func(o *distro.Options) distro.Distro {
+		opts := append(append([]PackageSearchOption{}, defaults...),
+			WithPackageNames(o.Names...),
+			WithMatchMode(o.MatchMode),
+		)
+		if len(o.Mirrors) > 0 {
+			opts = append(opts, WithMirrors(o.Mirrors...))
+		}
+		if len(o.Archs) > 0 {
+			opts = append(opts, WithArchs(o.Archs...))
+		}
+		if o.Logger != nil {
+			opts = append(opts, WithSearchLogger(o.Logger))
+		}
+
+		// The suites and the package indexes are listed by the mirrors and the Release files.
+		search := NewPackageSearch(opts...)
+		o.WarnIgnored(search.Name(), distro.OptionVersionRegex, distro.OptionRepos, distro.OptionRepoVariables)
+
+		return search
+	}
+}
@@ -0,0 +1,163 @@
+package arch
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/pacman"
+	"github.com/maxgio92/linux-packages/pkg/template"
+)
+
+type PackageSearch struct {
+	names          []string
+	matchMode      packages.MatchMode
+	mirrors        []string
+	repos          []string
+	variables      map[string][]string
+	archs          []string
+	snapshots      bool
+	snapshotsSince time.Time
+
+	logger *log.Logger
+}
+
+type PackageSearchOption func(s *PackageSearch)
+
+func WithPackageNames(names ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.names = names
+	}
+}
+
+// WithMatchMode sets how the package names are matched, that is
+// as exact names (default), glob patterns or RE2 regular expressions.
+func WithMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.matchMode = mode
+	}
+}
+
+// WithMirrors sets the mirrors to search the packages in, instead of DefaultMirrors.
+func WithMirrors(mirrors ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.mirrors = mirrors
+	}
+}
+
+func WithSearchLogger(logger *log.Logger) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.logger = logger
+	}
+}
+
+func WithRepoTemplates(repos ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.repos = repos
+	}
+}
+
+// WithRepoVariables sets the values of the variables of the repository templates,
+// which are expanded besides the {{ .arch }} one.
+func WithRepoVariables(vars map[string][]string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.variables = vars
+	}
+}
+
+func WithArchs(archs ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.archs = archs
+	}
+}
+
+// WithSnapshots enables the search in the Arch Linux Archive dated snapshots,
+// in addition to the current repositories. As the archive has a snapshot for every day,
+// it is disabled by default, and is meant to be restricted with WithSnapshotsSince.
+func WithSnapshots(snapshots bool) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.snapshots = snapshots
+	}
+}
+
+// WithSnapshotsSince restricts the search to the snapshots not older than the specified date.
+func WithSnapshotsSince(since time.Time) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.snapshotsSince = since
+	}
+}
+
+func NewPackageSearch(o ...PackageSearchOption) *PackageSearch {
+	search := &PackageSearch{
+		mirrors: DefaultMirrors,
+		repos:   DefaultReposT,
+		archs:   DefaultArchs,
+		logger:  log.New(),
+	}
+	for _, f := range o {
+		f(search)
+	}
+
+	return search
+}
+
+// Name returns the name of the distro.
+func (s *PackageSearch) Name() string {
+	return Name
+}
+
+// Seeds returns the URLs of the mirrors of the current repositories.
+func (s *PackageSearch) Seeds() []string {
+	return s.mirrors
+}
+
+// VersionStage returns the stage that adds the dated snapshots of the archive,
+// when enabled, to the current repositories of the mirrors.
+func (s *PackageSearch) VersionStage() packages.StageRunner {
+	return packages.StageFunc(func(ctx context.Context, data chan string) chan string {
+		if !s.snapshots {
+			return data
+		}
+
+		snapshots := packages.NewGenericProducer(
+			packages.WithSeeds(MirrorArchive),
+			packages.WithLogger(s.logger),
+		).Produce(ctx)
+		snapshots = NewSnapshotSearcher(
+			WithSince(s.snapshotsSince),
+			WithSnapshotLogger(s.logger),
+		).Run(ctx, snapshots)
+
+		return packages.Merge(ctx, data, snapshots)
+	})
+}
+
+// RepoStage returns the stage that streams the database URLs of the repositories.
+func (s *PackageSearch) RepoStage() packages.StageRunner {
+	return packages.StageFunc(func(ctx context.Context, data chan string) chan string {
+		t := template.NewMultiplexTemplate(
+			template.WithTemplates(s.repos...),
+			template.WithVariables(distro.RepoVariables(s.variables, s.archs)),
+		)
+		dbs, _ := t.Run()
+
+		return distro.StubStage(ctx, data, dbs)
+	})
+}
+
+// PackageStage returns the stage that searches the packages in the databases.
+func (s *PackageSearch) PackageStage() packages.SearchStageRunner {
+	return pacman.NewPackageSearcher(
+		pacman.WithPackageNames(s.names...),
+		pacman.WithPackageMatchMode(s.matchMode),
+		pacman.WithPackageLogger(s.logger),
+	)
+}
+
+// Search is a data streaming pipeline.
+func (s *PackageSearch) Search(ctx context.Context) chan *packages.Package {
+	return distro.Search(ctx, s, packages.WithLogger(s.logger))
+}
@@ -0,0 +1,23 @@
+//go:build all_tests || all_unit_tests || all_integration_tests || unit_tests || integration_tests
+
+package arch_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var m *httptest.Server
+
+func TestArch(t *testing.T) {
+	m = runMockArchive(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Arch Suite")
+}
+
+var _ = BeforeSuite(func() {
+	Expect(m.URL).ToNot(BeEmpty())
+})
@@ -0,0 +1,26 @@
+package arch
+
+const (
+	Name          = "arch"
+	MirrorEdge    = "https://mirrors.edge.kernel.org/archlinux/"
+	MirrorArchive = "https://archive.archlinux.org/repos/"
+	YearRegex     = `^[0-9]{4}\/?$`
+	MonthRegex    = `^[0-9]{2}\/?$`
+	DayRegex      = `^[0-9]{2}\/?$`
+	X86_64        = "x86_64"
+	RepoCore      = "core"
+	RepoExtra     = "extra"
+	RepoMultilib  = "multilib"
+)
+
+var (
+	// DefaultMirrors are the mirrors of the current repositories searched by default.
+	DefaultMirrors = []string{MirrorEdge}
+
+	DefaultReposT = []string{
+		"/" + RepoCore + "/os/{{ .arch }}/" + RepoCore + ".db",
+		"/" + RepoExtra + "/os/{{ .arch }}/" + RepoExtra + ".db",
+		"/" + RepoMultilib + "/os/{{ .arch }}/" + RepoMultilib + ".db",
+	}
+	DefaultArchs = []string{X86_64}
+)
@@ -0,0 +1,149 @@
+package arch
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	wfind "github.com/maxgio92/wfind/pkg/find"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// SnapshotSearcher searches the dated snapshots of the Arch Linux Archive,
+// that are laid out as <year>/<month>/<day> directories.
+type SnapshotSearcher struct {
+	since  time.Time
+	logger *log.Logger
+}
+
+type SnapshotSearchOption func(o *SnapshotSearcher)
+
+// WithSince restricts the search to the snapshots not older than the specified date.
+func WithSince(since time.Time) SnapshotSearchOption {
+	return func(search *SnapshotSearcher) {
+		search.since = since
+	}
+}
+
+func WithSnapshotLogger(logger *log.Logger) SnapshotSearchOption {
+	return func(search *SnapshotSearcher) {
+		search.logger = logger
+	}
+}
+
+func NewSnapshotSearcher(options ...SnapshotSearchOption) *SnapshotSearcher {
+	ss := &SnapshotSearcher{logger: log.New()}
+	for _, f := range options {
+		f(ss)
+	}
+
+	return ss
+}
+
+// Run runs a pipeline stage of which the output is a channel of snapshot URL strings.
+// The source of the stage is a channel of archive repositories root URL strings.
+func (s *SnapshotSearcher) Run(ctx context.Context, sourceCh chan string) chan string {
+	destCh := make(chan string)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			s.logger.WithField("mirror", source).Debug("receive")
+
+			// Years are searched concurrently.
+			var years []string
+			for _, year := range s.find(ctx, source, YearRegex) {
+				if !s.isBefore(year, 1) {
+					years = append(years, year)
+				}
+			}
+			yearsCh := make(chan string, len(years))
+			for _, year := range years {
+				yearsCh <- year
+			}
+			close(yearsCh)
+
+			packages.ForEach(ctx, yearsCh, func(year string) {
+				for _, month := range s.find(ctx, year, MonthRegex) {
+					if s.isBefore(month, 2) {
+						continue
+					}
+					for _, day := range s.find(ctx, month, DayRegex) {
+						if s.isBefore(day, 3) {
+							continue
+						}
+						s.logger.WithField("snapshot", day).Debug("send")
+						if !packages.Send(ctx, destCh, day) {
+							return
+						}
+					}
+				}
+			})
+		})
+	}()
+
+	return destCh
+}
+
+func (s *SnapshotSearcher) find(ctx context.Context, seed string, regex string) []string {
+	finder := wfind.NewFind(
+		wfind.WithSeedURLs([]string{seed}),
+		wfind.WithFilenameRegexp(regex),
+		wfind.WithFileType(wfind.FileTypeDir),
+		wfind.WithRecursive(false),
+		wfind.WithAsync(true),
+		wfind.WithContextDeadlineRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+		wfind.WithConnResetRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+		wfind.WithConnTimeoutRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+		wfind.WithClientTransport(network.FindTransport(ctx)),
+	)
+
+	found, err := network.Find(ctx, finder)
+	if err != nil {
+		s.logger.WithError(err).Debug("error searching arch snapshots")
+		packages.ReportError(ctx, "arch.SnapshotSearcher", seed, err)
+	}
+	if found == nil {
+		return nil
+	}
+
+	return found.URLs
+}
+
+// isBefore returns whether the snapshot directory, of which the URL ends with
+// the specified number of date elements (year, month and day), is older than
+// the oldest snapshot to search.
+func (s *SnapshotSearcher) isBefore(snapshotURL string, elements int) bool {
+	if s.since.IsZero() {
+		return false
+	}
+
+	parts := strings.Split(strings.Trim(snapshotURL, "/"), "/")
+	if len(parts) < elements {
+		return false
+	}
+
+	date := []int{1, 1, 1}
+	for i, v := range parts[len(parts)-elements:] {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return false
+		}
+		date[i] = n
+	}
+
+	since := []int{s.since.Year(), int(s.since.Month()), s.since.Day()}
+	for i := 0; i < elements; i++ {
+		if date[i] != since[i] {
+			return date[i] < since[i]
+		}
+	}
+
+	return false
+}
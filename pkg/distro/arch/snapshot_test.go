@@ -0,0 +1,152 @@
+//go:build all_tests || all_unit_tests || (unit_tests && arch && snapshot)
+
+package arch_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/distro/arch"
+)
+
+const (
+	homedir = "repos"
+)
+
+var (
+	// snapshots are the dated snapshots of the archive, by year and month.
+	snapshots = map[string]map[string][]string{
+		"2022": {"12": {"30", "31"}},
+		"2023": {"01": {"01", "02"}, "02": {"01"}},
+	}
+	listingF = `
+<html>
+<head><title>Index of /%s/</title></head>
+<body>
+<h1>Index of /%s/</h1><hr><pre><a href="../">../</a>
+%s</pre><hr></body>
+</html>
+`
+)
+
+func listing(dir string, entries ...string) string {
+	b := new(strings.Builder)
+	for _, v := range entries {
+		fmt.Fprintf(b, "<a href=\"%s/\">%s/</a>\n", v, v)
+	}
+
+	return fmt.Sprintf(listingF, dir, dir, b.String())
+}
+
+// runMockArchive starts a mock server that serves the directory listings of the archive.
+// The mocha mock server is not used here as its replies cannot be served more than once.
+func runMockArchive(t testing.TB) *httptest.Server {
+	mux := http.NewServeMux()
+	addListing := func(dir string, entries ...string) {
+		body := listing(dir, entries...)
+		mux.HandleFunc("/"+dir+"/", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(body))
+		})
+	}
+
+	years := []string{"last", "week"}
+	for year, months := range snapshots {
+		years = append(years, year)
+		var ms []string
+		for month, days := range months {
+			ms = append(ms, month)
+			addListing(homedir+"/"+year+"/"+month, days...)
+		}
+		addListing(homedir+"/"+year, ms...)
+	}
+	addListing(homedir, years...)
+
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+var _ = Describe("Archive snapshot search mock", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	DescribeTable("With seed URLs",
+		func(since time.Time, expectedDates []string) {
+			sourceCh := make(chan string)
+			var actual []string
+
+			// Test producer.
+			go func() {
+				seed, _ := url.JoinPath(m.URL, homedir)
+				sourceCh <- seed
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range arch.NewSnapshotSearcher(arch.WithSince(since)).Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			// Expected data.
+			expected := []string{}
+			for _, v := range expectedDates {
+				s, _ := url.JoinPath(m.URL, homedir, v+"/")
+				expected = append(expected, s)
+			}
+
+			Expect(actual).To(ConsistOf(expected))
+		},
+		Entry("Should stream all the dated snapshots",
+			time.Time{},
+			[]string{"2022/12/30", "2022/12/31", "2023/01/01", "2023/01/02", "2023/02/01"},
+		),
+		Entry("Should stream the snapshots not older than the date",
+			time.Date(2022, time.December, 31, 0, 0, 0, 0, time.UTC),
+			[]string{"2022/12/31", "2023/01/01", "2023/01/02", "2023/02/01"},
+		),
+		Entry("Should stream the snapshots not older than the month",
+			time.Date(2023, time.January, 2, 0, 0, 0, 0, time.UTC),
+			[]string{"2023/01/02", "2023/02/01"},
+		),
+	)
+})
+
+var _ = Describe("Package search", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	Context("With the default options", func() {
+		It("Should search only the live mirrors", func() {
+			d, err := distro.New(arch.Name, distro.WithMirrors(m.URL+"/"))
+			Expect(err).ToNot(HaveOccurred())
+
+			sourceCh := make(chan string)
+			go func() {
+				for _, v := range d.Seeds() {
+					sourceCh <- v
+				}
+				close(sourceCh)
+			}()
+
+			var actual []string
+			for v := range d.VersionStage().Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			Expect(actual).To(Equal([]string{m.URL + "/"}))
+		})
+	})
+})
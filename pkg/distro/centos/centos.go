@@ -1,106 +1,62 @@
 package centos
 
 import (
-	"context"
-	"net/url"
-	"sync"
-
 	log "github.com/sirupsen/logrus"
 
-	"github.com/maxgio92/linux-packages/pkg/packages"
-	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+	"github.com/maxgio92/linux-packages/pkg/distro/yum"
 	"github.com/maxgio92/linux-packages/pkg/template"
 )
 
-type PackageSearch struct {
-	names        []string
-	repos        []string
-	reposAll     bool
-	reposDefault bool
-	archs        []string
+// PackageSearch searches the CentOS packages.
+type PackageSearch = yum.PackageSearch
+
+type PackageSearchOption = yum.PackageSearchOption
 
-	logger *log.Logger
+// Defaults returns the options of the search of the CentOS packages.
+func Defaults() []yum.PackageSearchOption {
+	return []yum.PackageSearchOption{
+		yum.WithDistroName(Name),
+		yum.WithMirrors(DefaultMirrors...),
+		yum.WithVersionRegex(VersionRegex),
+		yum.WithDefaultRepoTemplates(DefaultReposT...),
+		yum.WithDefaultArchs(DefaultArchs...),
+	}
 }
 
-type PackageSearchOption func(s *PackageSearch)
+// NewPackageSearch returns the search of the CentOS packages, of which the defaults
+// are overridden by the options.
+func NewPackageSearch(o ...PackageSearchOption) *PackageSearch {
+	return yum.NewPackageSearch(append(Defaults(), o...)...)
+}
 
+// Deprecated: use yum.WithPackageNames.
 func WithPackageNames(names ...string) PackageSearchOption {
-	return func(search *PackageSearch) {
-		search.names = names
-	}
+	return yum.WithPackageNames(names...)
 }
 
+// Deprecated: use yum.WithSearchLogger.
 func WithSearchLogger(logger *log.Logger) PackageSearchOption {
-	return func(search *PackageSearch) {
-		search.logger = logger
-	}
+	return yum.WithSearchLogger(logger)
 }
 
+// Deprecated: use yum.WithRepoTemplates.
 func WithRepoTemplates(repos ...string) PackageSearchOption {
-	return func(search *PackageSearch) {
-		search.repos = repos
-	}
+	return yum.WithRepoTemplates(repos...)
 }
 
+// Deprecated: use yum.WithArchs.
 func WithArchs(archs ...string) PackageSearchOption {
-	return func(search *PackageSearch) {
-		search.archs = archs
-	}
+	return yum.WithArchs(archs...)
 }
 
+// Deprecated: use yum.WithAllRepos.
 func WithAllRepos(reposAll bool) PackageSearchOption {
-	return func(search *PackageSearch) {
-		search.reposAll = reposAll
-	}
+	return yum.WithAllRepos(reposAll)
 }
 
+// Deprecated: use yum.WithDefaultRepos.
 func WithDefaultRepos(reposDefault bool) PackageSearchOption {
-	return func(search *PackageSearch) {
-		search.reposDefault = reposDefault
-	}
-}
-
-func NewPackageSearch(o ...PackageSearchOption) *PackageSearch {
-	search := new(PackageSearch)
-	for _, f := range o {
-		f(search)
-	}
-
-	return search
-}
-
-// Search is a data streaming pipeline.
-func (s *PackageSearch) Search(ctx context.Context) chan *packages.Package {
-	data := packages.NewGenericProducer(
-		packages.WithSeeds(MirrorEdge, MirrorArchive),
-		packages.WithLogger(s.logger),
-	).Produce(ctx)
-	data = NewVersionSearcher(WithMirrorLogger(s.logger)).Run(ctx, data)
-
-	switch s.reposAll {
-	case true:
-		data = rpm.NewRepoSearcher(rpm.WithRepoLogger(s.logger)).Run(ctx, data)
-	case false:
-		repos := DefaultRepos()
-		if !s.reposDefault && len(s.repos) > 0 && len(s.archs) > 0 {
-			t := template.NewMultiplexTemplate(
-				template.WithTemplates(s.repos...),
-				template.WithVariables(map[string][]string{keyArch: s.archs}),
-			)
-
-			repos, _ = t.Run()
-		}
-		data = stubStage(ctx, data, repos)
-	default:
-		data = stubStage(ctx, data, DefaultRepos())
-	}
-
-	data = rpm.NewDBSearcher(rpm.WithDBLogger(s.logger)).Run(ctx, data)
-
-	return rpm.NewPackageSearcher(
-		rpm.WithPackageNames(s.names...),
-		rpm.WithPackageLogger(s.logger),
-	).Run(ctx, data)
+	return yum.WithDefaultRepos(reposDefault)
 }
 
 func DefaultRepos() []string {
@@ -113,29 +69,3 @@ func DefaultRepos() []string {
 
 	return repos
 }
-
-func stubStage(_ context.Context, seedsCh chan string, data []string) chan string {
-	destCh := make(chan string, len(data))
-
-	wg := sync.WaitGroup{}
-
-	for seed := range seedsCh {
-		seed := seed
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for k, _ := range data {
-				merged, err := url.JoinPath(seed, data[k])
-				if err == nil {
-					destCh <- merged
-				}
-			}
-		}()
-	}
-	go func() {
-		wg.Wait()
-		close(destCh)
-	}()
-
-	return destCh
-}
@@ -1,6 +1,7 @@
 package centos
 
 const (
+	Name          = "centos"
 	MirrorEdge    = "https://mirrors.edge.kernel.org/centos/"
 	MirrorArchive = "https://archive.kernel.org/centos-vault/"
 	//VersionRegex  = `^(0|[1-9]\d*)(\.(0|[1-9]\d*)?)?(\.(0|[1-9]\d*)?)?(-[a-zA-Z\d][-a-zA-Z.\d]*)?(\+[a-zA-Z\d][-a-zA-Z.\d]*)?\/?$`
@@ -13,6 +14,9 @@ const (
 )
 
 var (
+	// DefaultMirrors are the mirrors searched by default.
+	DefaultMirrors = []string{MirrorEdge, MirrorArchive}
+
 	defaultVersions = []string{"8-stream"}
 	DefaultReposT   = []string{
 		"/AppStream/{{ .arch }}/os/repodata/repomd.xml",
@@ -0,0 +1,31 @@
+package debian
+
+const (
+	Name           = "debian"
+	MirrorEdge     = "https://mirrors.edge.kernel.org/debian/"
+	MirrorSecurity = "https://security.debian.org/debian-security/"
+	MirrorArchive  = "https://archive.debian.org/debian/"
+	Amd64          = "amd64"
+	Arm64          = "arm64"
+	I386           = "i386"
+	Ppc64el        = "ppc64el"
+	All            = "all"
+)
+
+var (
+	// DefaultMirrors are the mirrors searched by default.
+	DefaultMirrors = []string{MirrorEdge, MirrorSecurity, MirrorArchive}
+
+	// SuiteAliases are the suites that are symbolic links to the codenames.
+	SuiteAliases = []string{
+		"stable",
+		"oldstable",
+		"oldoldstable",
+		"testing",
+		"unstable",
+		"experimental",
+		"rc-buggy",
+	}
+	DefaultComponents = []string{"main", "contrib", "non-free", "non-free-firmware"}
+	DefaultArchs      = []string{Amd64, Arm64, I386, Ppc64el, All}
+)
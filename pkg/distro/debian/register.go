@@ -0,0 +1,10 @@
+package debian
+
+import (
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/distro/apt"
+)
+
+func init() {
+	distro.Register(Name, apt.Factory(Defaults()...))
+}
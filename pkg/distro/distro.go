@@ -0,0 +1,240 @@
+package distro
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// Distro is a Linux distribution of which the packages can be searched,
+// as a pipeline of stages: the seeds are streamed to the version discovery stage,
+// of which the output feeds the repository stage, of which the output
+// feeds the package stage of the package format backend.
+type Distro interface {
+	// Name returns the name with which the distro is registered.
+	Name() string
+
+	// Seeds returns the URLs of the mirrors the search starts from.
+	Seeds() []string
+
+	// VersionStage returns the stage that streams the URLs of the release versions,
+	// from the seeds.
+	VersionStage() packages.StageRunner
+
+	// RepoStage returns the stage that streams the URLs of the repository metadata,
+	// from the release versions.
+	RepoStage() packages.StageRunner
+
+	// PackageStage returns the stage that streams the packages found in the repositories.
+	PackageStage() packages.SearchStageRunner
+}
+
+// Options are the options of the search common to the distros.
+// The distros ignore the options that they do not support, warning about
+// the ones of the configuration, and fall back to their defaults for the unset ones.
+type Options struct {
+	Names          []string
+	MatchMode      packages.MatchMode
+	FilePaths      []string
+	Provides       []string
+	Requires       []string
+	Mirrors        []string
+	VersionRegex   string
+	Repos          []string
+	RepoVariables  map[string][]string
+	Archs          []string
+	AllRepos       bool
+	Versions       []string
+	Latest         bool
+	StreamMirrors  bool
+	Snapshots      bool
+	SnapshotsSince time.Time
+	Logger         *log.Logger
+}
+
+type Option func(o *Options)
+
+func WithNames(names ...string) Option {
+	return func(o *Options) {
+		o.Names = names
+	}
+}
+
+func WithMatchMode(mode packages.MatchMode) Option {
+	return func(o *Options) {
+		o.MatchMode = mode
+	}
+}
+
+// WithFilePaths sets the paths of the files, that can be glob patterns, shipped by the packages
+// to search for, instead of their names.
+func WithFilePaths(paths ...string) Option {
+	return func(o *Options) {
+		o.FilePaths = paths
+	}
+}
+
+// WithProvides sets the capabilities provided by the packages to search for, instead of their names.
+func WithProvides(capabilities ...string) Option {
+	return func(o *Options) {
+		o.Provides = capabilities
+	}
+}
+
+// WithRequires sets the capabilities required by the packages to search for, instead of their names.
+func WithRequires(capabilities ...string) Option {
+	return func(o *Options) {
+		o.Requires = capabilities
+	}
+}
+
+func WithMirrors(mirrors ...string) Option {
+	return func(o *Options) {
+		o.Mirrors = mirrors
+	}
+}
+
+func WithVersionRegex(re string) Option {
+	return func(o *Options) {
+		o.VersionRegex = re
+	}
+}
+
+// WithRepos sets the templates of the repository paths, with the {{ .arch }} variable.
+func WithRepos(repos ...string) Option {
+	return func(o *Options) {
+		o.Repos = repos
+	}
+}
+
+// WithRepoVariables sets the values of the variables of the repository templates,
+// which are expanded besides the {{ .arch }} one.
+func WithRepoVariables(vars map[string][]string) Option {
+	return func(o *Options) {
+		o.RepoVariables = vars
+	}
+}
+
+func WithArchs(archs ...string) Option {
+	return func(o *Options) {
+		o.Archs = archs
+	}
+}
+
+func WithAllRepos(allRepos bool) Option {
+	return func(o *Options) {
+		o.AllRepos = allRepos
+	}
+}
+
+// WithVersions sets the constraints that the versions of the packages must all satisfy.
+func WithVersions(constraints ...string) Option {
+	return func(o *Options) {
+		o.Versions = constraints
+	}
+}
+
+// WithLatest sets whether to return only the newest build of each package.
+func WithLatest(latest bool) Option {
+	return func(o *Options) {
+		o.Latest = latest
+	}
+}
+
+// WithStreamMirrors sets whether to return the packages found through more mirrors as soon as
+// they are found the first time, without the other mirrors. By default, they are merged in a
+// single package with the mirrors as alternative locations, returned when the search completes.
+func WithStreamMirrors(stream bool) Option {
+	return func(o *Options) {
+		o.StreamMirrors = stream
+	}
+}
+
+// WithSnapshots sets whether to search the dated snapshots of the distros that archive them.
+func WithSnapshots(snapshots bool) Option {
+	return func(o *Options) {
+		o.Snapshots = snapshots
+	}
+}
+
+// WithSnapshotsSince restricts the search to the snapshots not older than the specified date.
+func WithSnapshotsSince(since time.Time) Option {
+	return func(o *Options) {
+		o.SnapshotsSince = since
+	}
+}
+
+func WithLogger(logger *log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// The names of the options that not all the distros support.
+const (
+	OptionVersionRegex  = "versionRegex"
+	OptionRepos         = "repos"
+	OptionRepoVariables = "variables"
+)
+
+// WarnIgnored logs a warning for each of the options, among the ones that not all
+// the distros support, that is set while the distro does not support it.
+func (o *Options) WarnIgnored(name string, options ...string) {
+	logger := o.Logger
+	if logger == nil {
+		logger = log.New()
+	}
+
+	for _, v := range options {
+		var set bool
+		switch v {
+		case OptionVersionRegex:
+			set = o.VersionRegex != ""
+		case OptionRepos:
+			set = len(o.Repos) > 0
+		case OptionRepoVariables:
+			set = len(o.RepoVariables) > 0
+		}
+		if set {
+			logger.WithField("distro", name).WithField("option", v).Warn("the option is not supported by the distro, and is ignored")
+		}
+	}
+}
+
+// KeyArch is the variable of the repository templates expanded with the architectures.
+const KeyArch = "arch"
+
+// RepoVariables returns the variables with which the repository templates are expanded,
+// that is the variables set with WithRepoVariables and the architectures.
+func RepoVariables(vars map[string][]string, archs []string) map[string][]string {
+	merged := make(map[string][]string, len(vars)+1)
+	for k, v := range vars {
+		merged[k] = v
+	}
+	merged[KeyArch] = archs
+
+	return merged
+}
+
+// Search runs the search pipeline of the distro.
+func Search(ctx context.Context, d Distro, o ...packages.GenericProducerOption) chan *packages.Package {
+	producer := packages.NewGenericProducer(
+		append([]packages.GenericProducerOption{packages.WithSeeds(d.Seeds()...)}, o...)...,
+	)
+
+	return packages.RunSearchPipeline(ctx, producer, d.PackageStage(), d.VersionStage(), d.RepoStage())
+}
+
+// SearchAll runs the search pipelines of the distros concurrently,
+// and merges the packages found in a single stream.
+func SearchAll(ctx context.Context, distros []Distro, o ...packages.GenericProducerOption) chan *packages.Package {
+	sources := make([]chan *packages.Package, 0, len(distros))
+	for _, d := range distros {
+		sources = append(sources, Search(ctx, d, o...))
+	}
+
+	return packages.Merge(ctx, sources...)
+}
@@ -0,0 +1,33 @@
+package fedora
+
+const (
+	Name          = "fedora"
+	MirrorEdge    = "https://mirrors.edge.kernel.org/fedora/"
+	MirrorArchive = "https://archives.fedoraproject.org/pub/archive/fedora/linux/"
+	DirReleases   = "releases/"
+	DirUpdates    = "updates/"
+	DirTesting    = "updates/testing/"
+	VersionRegex  = `^[0-9]+\/?$`
+	X86_64        = "x86_64"
+	Aarch64       = "aarch64"
+	Ppc64le       = "ppc64le"
+	S390x         = "s390x"
+)
+
+var (
+	// DefaultMirrors are the mirrors searched by default.
+	DefaultMirrors = []string{MirrorEdge, MirrorArchive}
+
+	// DefaultDirs are the directories under the mirror roots
+	// that contain the release versions.
+	DefaultDirs   = []string{DirReleases, DirUpdates, DirTesting}
+	DefaultReposT = []string{
+		// Releases.
+		"/Everything/{{ .arch }}/os/repodata/repomd.xml",
+		"/Fedora/{{ .arch }}/os/repodata/repomd.xml",
+		// Updates and updates-testing.
+		"/Everything/{{ .arch }}/repodata/repomd.xml",
+		"/{{ .arch }}/repodata/repomd.xml",
+	}
+	DefaultArchs = []string{X86_64, Aarch64, Ppc64le, S390x}
+)
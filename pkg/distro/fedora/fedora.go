@@ -0,0 +1,40 @@
+package fedora
+
+import (
+	"net/url"
+
+	"github.com/maxgio92/linux-packages/pkg/distro/yum"
+)
+
+// Defaults returns the options of the search of the Fedora packages.
+func Defaults() []yum.PackageSearchOption {
+	return []yum.PackageSearchOption{
+		yum.WithDistroName(Name),
+		yum.WithMirrors(DefaultMirrors...),
+		yum.WithSeeds(Seeds),
+		yum.WithVersionRegex(VersionRegex),
+		yum.WithDefaultRepoTemplates(DefaultReposT...),
+		yum.WithDefaultArchs(DefaultArchs...),
+	}
+}
+
+// NewPackageSearch returns the search of the Fedora packages, of which the defaults
+// are overridden by the options.
+func NewPackageSearch(o ...yum.PackageSearchOption) *yum.PackageSearch {
+	return yum.NewPackageSearch(append(Defaults(), o...)...)
+}
+
+// Seeds returns the URLs of the directories that contain the release versions,
+// for each of the mirrors.
+func Seeds(mirrors ...string) []string {
+	var seeds []string
+	for _, mirror := range mirrors {
+		for _, dir := range DefaultDirs {
+			if seed, err := url.JoinPath(mirror, dir); err == nil {
+				seeds = append(seeds, seed)
+			}
+		}
+	}
+
+	return seeds
+}
@@ -0,0 +1,112 @@
+//go:build all_tests || all_unit_tests || (unit_tests && fedora && mirror)
+
+package fedora_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vitorsalgado/mocha/v3"
+	"github.com/vitorsalgado/mocha/v3/expect"
+	"github.com/vitorsalgado/mocha/v3/reply"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/distro/fedora"
+)
+
+const (
+	homedir = "updates"
+)
+
+var (
+	versions    = []string{"37", "38", "39"}
+	homedirBody = fmt.Sprintf(`
+<html>
+<head><title>Index of %s/</title></head>
+<body>
+<h1>Index of /%s/</h1><hr><pre><a href="../">../</a>
+<a href="%s/">%s/</a>
+<a href="%s/">%s/</a>
+<a href="%s/">%s/</a>
+<a href="testing/">testing/</a>
+<a href="README">README</a>
+</pre><hr></body>
+</html>
+`, homedir,
+		homedir,
+		versions[0], versions[0],
+		versions[1], versions[1],
+		versions[2], versions[2])
+)
+
+func runMockMirror(t testing.TB) *mocha.Mocha {
+	m := mocha.New(t).CloseOnCleanup(t)
+
+	m.AddMocks(
+		// home dir.
+		mocha.Get(expect.URLPath(fmt.Sprintf("/%s/", homedir)).
+			Or(expect.URLPath(fmt.Sprintf("/%s", homedir)))).
+			Reply(reply.OK().BodyString(homedirBody)))
+
+	m.Start()
+
+	return m
+}
+
+var _ = Describe("Mirror version search mock", func() {
+	var (
+		search *distro.VersionSearcher
+		ctx    = context.Background()
+	)
+
+	Context("With versions", func() {
+		BeforeEach(func() {
+			search = distro.NewVersionSearcher(distro.WithVersionSearcherRegex(fedora.VersionRegex))
+		})
+		Context("with seed URLs", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan string)
+				actual   []string
+				expected []string
+			)
+			BeforeAll(func() {
+
+				// Test producer.
+				go func() {
+					seed, _ := url.JoinPath(m.URL(), homedir)
+					sourceCh <- seed
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+
+				// Expected data.
+				expected = []string{}
+				for _, v := range versions {
+					s, _ := url.JoinPath(m.URL(), homedir, v+"/")
+					expected = append(expected, s)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stream results", func() {
+				Expect(actual).ToNot(BeEmpty())
+			})
+			It("Should stream only release versions", func() {
+				Expect(actual).To(ConsistOf(expected))
+			})
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package opensuse
+
+const (
+	Name          = "opensuse"
+	MirrorEdge    = "https://mirrors.edge.kernel.org/opensuse/"
+	MirrorArchive = "https://ftp.gwdg.de/pub/opensuse/discontinued/"
+	DirLeap       = "distribution/leap/"
+	DirLeapUpdate = "update/leap/"
+	VersionRegex  = `^[0-9]+\.[0-9]+\/?$`
+)
+
+var (
+	// DefaultMirrors are the mirrors searched by default.
+	DefaultMirrors = []string{MirrorEdge, MirrorArchive}
+
+	// DefaultDirs are the directories under the mirror roots
+	// that contain the Leap release versions.
+	DefaultDirs = []string{DirLeap, DirLeapUpdate}
+
+	// DefaultReposT are the repositories of each Leap release version,
+	// both for the distribution and for the updates.
+	DefaultReposT = []string{
+		// Distribution.
+		"/repo/oss/repodata/repomd.xml",
+		"/repo/non-oss/repodata/repomd.xml",
+		// Updates.
+		"/oss/repodata/repomd.xml",
+		"/non-oss/repodata/repomd.xml",
+		"/sle/repodata/repomd.xml",
+		"/backports/repodata/repomd.xml",
+	}
+
+	// TumbleweedRepos are the repositories of the rolling release,
+	// relative to the mirror roots.
+	TumbleweedRepos = []string{
+		"tumbleweed/repo/oss/repodata/repomd.xml",
+		"tumbleweed/repo/non-oss/repodata/repomd.xml",
+		"update/tumbleweed/repodata/repomd.xml",
+	}
+)
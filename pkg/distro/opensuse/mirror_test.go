@@ -0,0 +1,109 @@
+//go:build all_tests || all_unit_tests || (unit_tests && opensuse && mirror)
+
+package opensuse_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/distro/opensuse"
+)
+
+const (
+	homedir = "distribution/leap"
+)
+
+var (
+	versions    = []string{"15.4", "15.5", "42.3"}
+	homedirBody = fmt.Sprintf(`
+<html>
+<head><title>Index of /%s/</title></head>
+<body>
+<h1>Index of /%s/</h1><hr><pre><a href="../">../</a>
+<a href="%s/">%s/</a>
+<a href="%s/">%s/</a>
+<a href="%s/">%s/</a>
+<a href="15.5-Micro/">15.5-Micro/</a>
+<a href="README">README</a>
+</pre><hr></body>
+</html>
+`, homedir,
+		homedir,
+		versions[0], versions[0],
+		versions[1], versions[1],
+		versions[2], versions[2])
+)
+
+func runMockMirror(t testing.TB) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+homedir+"/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(homedirBody))
+	})
+
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+var _ = Describe("Mirror version search mock", func() {
+	var (
+		search *distro.VersionSearcher
+		ctx    = context.Background()
+	)
+
+	Context("With versions", func() {
+		BeforeEach(func() {
+			search = distro.NewVersionSearcher(distro.WithVersionSearcherRegex(opensuse.VersionRegex))
+		})
+		Context("with seed URLs", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan string)
+				actual   []string
+				expected []string
+			)
+			BeforeAll(func() {
+
+				// Test producer.
+				go func() {
+					seed, _ := url.JoinPath(m.URL, homedir)
+					sourceCh <- seed
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+
+				// Expected data.
+				expected = []string{}
+				for _, v := range versions {
+					s, _ := url.JoinPath(m.URL, homedir, v+"/")
+					expected = append(expected, s)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stream results", func() {
+				Expect(actual).ToNot(BeEmpty())
+			})
+			It("Should stream only the Leap versions", func() {
+				Expect(actual).To(ConsistOf(expected))
+			})
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package opensuse
+
+import (
+	"net/url"
+
+	"github.com/maxgio92/linux-packages/pkg/distro/yum"
+)
+
+// Defaults returns the options of the search of the openSUSE packages,
+// both of the Leap releases and of Tumbleweed.
+func Defaults() []yum.PackageSearchOption {
+	return []yum.PackageSearchOption{
+		yum.WithDistroName(Name),
+		yum.WithMirrors(DefaultMirrors...),
+		yum.WithSeeds(Seeds),
+		yum.WithVersionRegex(VersionRegex),
+		yum.WithDefaultRepoTemplates(DefaultReposT...),
+		WithTumbleweed(true),
+	}
+}
+
+// NewPackageSearch returns the search of the openSUSE packages, of which the defaults
+// are overridden by the options.
+func NewPackageSearch(o ...yum.PackageSearchOption) *yum.PackageSearch {
+	return yum.NewPackageSearch(append(Defaults(), o...)...)
+}
+
+// WithTumbleweed sets whether to search the Tumbleweed rolling release repositories,
+// in addition to the Leap ones. Tumbleweed is served by the first mirror only,
+// as the others archive the discontinued releases.
+func WithTumbleweed(tumbleweed bool) yum.PackageSearchOption {
+	if !tumbleweed {
+		return yum.WithRootRepos()
+	}
+
+	return yum.WithRootRepos(TumbleweedRepos...)
+}
+
+// Seeds returns the URLs of the directories that contain the Leap release versions,
+// for each of the mirrors.
+func Seeds(mirrors ...string) []string {
+	var seeds []string
+	for _, mirror := range mirrors {
+		for _, dir := range DefaultDirs {
+			if seed, err := url.JoinPath(mirror, dir); err == nil {
+				seeds = append(seeds, seed)
+			}
+		}
+	}
+
+	return seeds
+}
@@ -0,0 +1,57 @@
+//go:build all_tests || all_unit_tests || (unit_tests && opensuse && mirror)
+
+package opensuse_test
+
+import (
+	"context"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/distro/opensuse"
+)
+
+var _ = Describe("Distro", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	It("Should search the Leap directories of the default mirrors", func() {
+		d, err := distro.New(opensuse.Name)
+		Expect(err).To(BeNil())
+
+		Expect(d.Seeds()).To(ConsistOf(
+			opensuse.MirrorEdge+opensuse.DirLeap,
+			opensuse.MirrorEdge+opensuse.DirLeapUpdate,
+			opensuse.MirrorArchive+opensuse.DirLeap,
+			opensuse.MirrorArchive+opensuse.DirLeapUpdate,
+		))
+	})
+
+	It("Should search the Leap versions by default", func() {
+		d, err := distro.New(opensuse.Name, distro.WithMirrors(m.URL+"/"))
+		Expect(err).To(BeNil())
+
+		seeds := make(chan string)
+		go func() {
+			defer close(seeds)
+			for _, v := range d.Seeds() {
+				seeds <- v
+			}
+		}()
+
+		var actual []string
+		for v := range d.VersionStage().Run(ctx, seeds) {
+			actual = append(actual, v)
+		}
+
+		expected := []string{}
+		for _, v := range versions {
+			s, _ := url.JoinPath(m.URL, homedir, v+"/")
+			expected = append(expected, s)
+		}
+		Expect(actual).To(ConsistOf(expected))
+	})
+})
@@ -0,0 +1,10 @@
+package opensuse
+
+import (
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/distro/yum"
+)
+
+func init() {
+	distro.Register(Name, yum.Factory(Defaults()...))
+}
@@ -0,0 +1,68 @@
+package distro
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrDistroNotRegistered = errors.New("the distro is not registered")
+)
+
+// Factory returns a distro configured with the options.
+type Factory func(o *Options) Distro
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a distro available by name. It is meant to be called
+// from the init functions of the distro packages, and panics if the
+// name is registered twice or the factory is nil.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if f == nil {
+		panic("distro: register factory is nil for " + name)
+	}
+	if _, ok := registry[name]; ok {
+		panic("distro: register called twice for " + name)
+	}
+	registry[name] = f
+}
+
+// New returns the registered distro configured with the options.
+func New(name string, o ...Option) (Distro, error) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, errors.Wrap(ErrDistroNotRegistered, name)
+	}
+
+	options := new(Options)
+	for _, opt := range o {
+		opt(options)
+	}
+
+	return f(options), nil
+}
+
+// Names returns the sorted names of the registered distros.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for k := range registry {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return names
+}
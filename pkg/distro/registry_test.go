@@ -0,0 +1,186 @@
+//go:build all_tests || all_unit_tests || (unit_tests && registry)
+
+package distro_test
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+const (
+	fakeA = "fake-a"
+	fakeB = "fake-b"
+)
+
+// fakeDistro streams the seeds through stages that append the path
+// of the version and of the repository, and emits a package per repository.
+type fakeDistro struct {
+	name    string
+	options *distro.Options
+}
+
+func (d *fakeDistro) Name() string {
+	return d.name
+}
+
+func (d *fakeDistro) Seeds() []string {
+	return d.options.Mirrors
+}
+
+func (d *fakeDistro) VersionStage() packages.StageRunner {
+	return appendStage("1/")
+}
+
+func (d *fakeDistro) RepoStage() packages.StageRunner {
+	return appendStage("os/")
+}
+
+func (d *fakeDistro) PackageStage() packages.SearchStageRunner {
+	return packages.SearchStageFunc(func(_ context.Context, source chan string) chan *packages.Package {
+		destCh := make(chan *packages.Package)
+		go func() {
+			defer close(destCh)
+			for v := range source {
+				for _, name := range d.options.Names {
+					destCh <- packages.NewPackage(
+						packages.WithName(name),
+						packages.WithRepository(v),
+						packages.WithLocation(v+name+".pkg"),
+					)
+				}
+			}
+		}()
+
+		return destCh
+	})
+}
+
+func appendStage(suffix string) packages.StageFunc {
+	return func(_ context.Context, source chan string) chan string {
+		destCh := make(chan string)
+		go func() {
+			defer close(destCh)
+			for v := range source {
+				destCh <- v + suffix
+			}
+		}()
+
+		return destCh
+	}
+}
+
+func init() {
+	for _, name := range []string{fakeA, fakeB} {
+		name := name
+		distro.Register(name, func(o *distro.Options) distro.Distro {
+			return &fakeDistro{name: name, options: o}
+		})
+	}
+}
+
+var _ = Describe("Registry", func() {
+	ctx := context.Background()
+
+	Context("Register", func() {
+		It("Should panic on a name registered twice", func() {
+			Expect(func() {
+				distro.Register(fakeA, func(o *distro.Options) distro.Distro { return nil })
+			}).To(Panic())
+		})
+		It("Should panic on a nil factory", func() {
+			Expect(func() { distro.Register("fake-nil", nil) }).To(Panic())
+		})
+	})
+
+	Context("Names", func() {
+		It("Should return the sorted registered names", func() {
+			Expect(distro.Names()).To(ContainElements(fakeA, fakeB))
+			Expect(distro.Names()).NotTo(ContainElement("fake-nil"))
+			Expect(sort.StringsAreSorted(distro.Names())).To(BeTrue())
+		})
+	})
+
+	Context("New", func() {
+		It("Should return the distro configured with the options", func() {
+			d, err := distro.New(fakeA,
+				distro.WithNames("kernel-devel"),
+				distro.WithMirrors("https://a.example.com/"),
+				distro.WithLatest(true),
+			)
+			Expect(err).To(BeNil())
+			Expect(d.Name()).To(Equal(fakeA))
+			Expect(d.Seeds()).To(Equal([]string{"https://a.example.com/"}))
+			Expect(d.(*fakeDistro).options.Latest).To(BeTrue())
+		})
+		It("Should pass the file path, capability and snapshot searches to the distro", func() {
+			d, err := distro.New(fakeA,
+				distro.WithFilePaths("/usr/src/kernels/*/Makefile"),
+				distro.WithProvides("kernel-devel-uname-r = 5.14.0"),
+				distro.WithRequires("bash"),
+				distro.WithSnapshots(true),
+			)
+			Expect(err).To(BeNil())
+			o := d.(*fakeDistro).options
+			Expect(o.FilePaths).To(Equal([]string{"/usr/src/kernels/*/Makefile"}))
+			Expect(o.Provides).To(Equal([]string{"kernel-devel-uname-r = 5.14.0"}))
+			Expect(o.Requires).To(Equal([]string{"bash"}))
+			Expect(o.Snapshots).To(BeTrue())
+		})
+		It("Should fail on a distro not registered", func() {
+			_, err := distro.New("fake-unknown")
+			Expect(err).To(MatchError(distro.ErrDistroNotRegistered))
+		})
+	})
+
+	Context("WarnIgnored", func() {
+		It("Should warn about the options set that the distro does not support", func() {
+			out := new(bytes.Buffer)
+			logger := log.New()
+			logger.SetOutput(out)
+
+			o := &distro.Options{Repos: []string{"/os/repodata/repomd.xml"}, Logger: logger}
+			o.WarnIgnored(fakeA, distro.OptionVersionRegex, distro.OptionRepos, distro.OptionRepoVariables)
+
+			Expect(out.String()).To(ContainSubstring("option=repos"))
+			Expect(out.String()).NotTo(ContainSubstring("option=versionRegex"))
+			Expect(out.String()).NotTo(ContainSubstring("option=variables"))
+		})
+	})
+
+	Context("SearchAll", func() {
+		It("Should merge the packages of all the distros", func() {
+			a, err := distro.New(fakeA,
+				distro.WithNames("kernel-devel"),
+				distro.WithMirrors("https://a.example.com/", "https://mirror.a.example.com/"),
+			)
+			Expect(err).To(BeNil())
+			b, err := distro.New(fakeB,
+				distro.WithNames("kernel-headers"),
+				distro.WithMirrors("https://b.example.com/"),
+			)
+			Expect(err).To(BeNil())
+
+			locations := []string{}
+			for pkg := range distro.SearchAll(ctx, []distro.Distro{a, b}) {
+				locations = append(locations, pkg.Locate())
+			}
+
+			Expect(locations).To(ConsistOf(
+				"https://a.example.com/1/os/kernel-devel.pkg",
+				"https://mirror.a.example.com/1/os/kernel-devel.pkg",
+				"https://b.example.com/1/os/kernel-headers.pkg",
+			))
+		})
+		It("Should close the stream without distros", func() {
+			Eventually(distro.SearchAll(ctx, nil)).Should(BeClosed())
+		})
+	})
+})
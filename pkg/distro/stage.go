@@ -0,0 +1,119 @@
+package distro
+
+import (
+	"context"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+
+	wfind "github.com/maxgio92/wfind/pkg/find"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// DefaultVersionRegex matches the names of all the directories.
+const DefaultVersionRegex = `^.+\/?$`
+
+// VersionSearcher is a pipeline stage that searches the release versions of a distro,
+// as the directories of the mirrors of which the names match a regular expression.
+type VersionSearcher struct {
+	name         string
+	versionRegex string
+	logger       *log.Logger
+}
+
+type VersionSearcherOption func(s *VersionSearcher)
+
+// WithVersionSearcherName sets the name of the distro, with which the failures are reported.
+func WithVersionSearcherName(name string) VersionSearcherOption {
+	return func(search *VersionSearcher) {
+		search.name = name
+	}
+}
+
+// WithVersionSearcherRegex sets the regular expression that the names
+// of the version directories match, instead of DefaultVersionRegex.
+func WithVersionSearcherRegex(re string) VersionSearcherOption {
+	return func(search *VersionSearcher) {
+		search.versionRegex = re
+	}
+}
+
+func WithVersionSearcherLogger(logger *log.Logger) VersionSearcherOption {
+	return func(search *VersionSearcher) {
+		search.logger = logger
+	}
+}
+
+func NewVersionSearcher(o ...VersionSearcherOption) *VersionSearcher {
+	vs := &VersionSearcher{name: "distro", versionRegex: DefaultVersionRegex, logger: log.New()}
+	for _, f := range o {
+		f(vs)
+	}
+
+	return vs
+}
+
+// Run runs a pipeline stage of which the output is a channel of release version URL strings.
+// The source of the stage is a channel of URL strings of the directories that contain the versions.
+func (vs *VersionSearcher) Run(ctx context.Context, sourceCh chan string) chan string {
+	destCh := make(chan string)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			vs.logger.WithField("mirror", source).Debug("receive")
+			finder := wfind.NewFind(
+				wfind.WithSeedURLs([]string{source}),
+				wfind.WithFilenameRegexp(vs.versionRegex),
+				wfind.WithFileType(wfind.FileTypeDir),
+				wfind.WithRecursive(false),
+				wfind.WithAsync(true),
+				wfind.WithContextDeadlineRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+				wfind.WithConnResetRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+				wfind.WithConnTimeoutRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+				wfind.WithClientTransport(network.FindTransport(ctx)),
+			)
+
+			found, err := network.Find(ctx, finder)
+			if err != nil {
+				vs.logger.WithField("distro", vs.name).WithError(err).Debug("error searching versions")
+				packages.ReportError(ctx, vs.name+".VersionSearcher", source, err)
+			}
+			if found != nil {
+				for _, v := range found.URLs {
+					v := v
+					vs.logger.WithField("version", v).Debug("send")
+					if !packages.Send(ctx, destCh, v) {
+						return
+					}
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+// StubStage returns a channel of the URLs of the paths, joined to each of the seeds
+// received from seedsCh, such as the known repositories of each release version.
+func StubStage(ctx context.Context, seedsCh chan string, paths []string) chan string {
+	destCh := make(chan string, len(paths))
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, seedsCh, func(seed string) {
+			for _, v := range paths {
+				merged, err := url.JoinPath(seed, v)
+				if err == nil && !packages.Send(ctx, destCh, merged) {
+					return
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
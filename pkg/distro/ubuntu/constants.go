@@ -0,0 +1,24 @@
+package ubuntu
+
+const (
+	Name          = "ubuntu"
+	MirrorEdge    = "https://mirrors.edge.kernel.org/ubuntu/"
+	MirrorPorts   = "https://ports.ubuntu.com/ubuntu-ports/"
+	MirrorArchive = "https://old-releases.ubuntu.com/ubuntu/"
+	Amd64         = "amd64"
+	Arm64         = "arm64"
+	I386          = "i386"
+	Ppc64el       = "ppc64el"
+	S390x         = "s390x"
+	All           = "all"
+)
+
+var (
+	// DefaultMirrors are the mirrors searched by default.
+	DefaultMirrors = []string{MirrorEdge, MirrorPorts, MirrorArchive}
+
+	// SuiteAliases are the suites that are symbolic links to the codenames.
+	SuiteAliases      = []string{"devel"}
+	DefaultComponents = []string{"main", "restricted", "universe", "multiverse"}
+	DefaultArchs      = []string{Amd64, Arm64, I386, Ppc64el, S390x, All}
+)
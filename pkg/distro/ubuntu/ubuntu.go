@@ -0,0 +1,22 @@
+package ubuntu
+
+import (
+	"github.com/maxgio92/linux-packages/pkg/distro/apt"
+)
+
+// Defaults returns the options of the search of the Ubuntu packages.
+func Defaults() []apt.PackageSearchOption {
+	return []apt.PackageSearchOption{
+		apt.WithDistroName(Name),
+		apt.WithMirrors(DefaultMirrors...),
+		apt.WithSuiteAliases(SuiteAliases...),
+		apt.WithComponents(DefaultComponents...),
+		apt.WithArchs(DefaultArchs...),
+	}
+}
+
+// NewPackageSearch returns the search of the Ubuntu packages, of which the defaults
+// are overridden by the options.
+func NewPackageSearch(o ...apt.PackageSearchOption) *apt.PackageSearch {
+	return apt.NewPackageSearch(append(Defaults(), o...)...)
+}
@@ -0,0 +1,372 @@
+package yum
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/distro"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+	"github.com/maxgio92/linux-packages/pkg/template"
+)
+
+// PackageSearch searches the packages of the distros with RPM repositories, like CentOS, Fedora
+// and openSUSE, which differ only in their mirrors, release versions and repositories.
+type PackageSearch struct {
+	name         string
+	names        []string
+	matchMode    packages.MatchMode
+	filePaths    []string
+	provides     []string
+	requires     []string
+	versions     []string
+	latest       bool
+	mergeMirrors bool
+	mirrors      []string
+	seeds        func(mirrors ...string) []string
+	versionRegex string
+	repos        []string
+	variables    map[string][]string
+	reposAll     bool
+	reposDefault bool
+	archs        []string
+	defaultRepos []string
+	defaultArchs []string
+	rootRepos    []string
+
+	logger *log.Logger
+}
+
+type PackageSearchOption func(s *PackageSearch)
+
+// WithDistroName sets the name of the distro.
+func WithDistroName(name string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.name = name
+	}
+}
+
+func WithPackageNames(names ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.names = names
+	}
+}
+
+// WithFilePaths sets the paths of the files, that can be glob patterns, shipped by the packages
+// to search for. When set, the packages are searched by file path instead of by name.
+func WithFilePaths(paths ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.filePaths = paths
+	}
+}
+
+// WithProvides sets the capabilities, optionally with version constraints, provided by the packages
+// to search for. When set, the packages are searched by capability instead of by name.
+func WithProvides(capabilities ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.provides = capabilities
+	}
+}
+
+// WithRequires sets the capabilities, optionally with version constraints, required by the packages
+// to search for. When set, the packages are searched by capability instead of by name.
+func WithRequires(capabilities ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.requires = capabilities
+	}
+}
+
+// WithVersions sets the constraints, such as ">= 5.14" and "< 6", that the versions
+// of the packages found must all satisfy.
+func WithVersions(constraints ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.versions = constraints
+	}
+}
+
+// WithLatest sets whether to return only the newest build of each package name
+// and architecture, per repository.
+func WithLatest(latest bool) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.latest = latest
+	}
+}
+
+// WithMergeMirrors sets whether to merge the packages found in more mirrors in a single package,
+// of which the mirrors are the alternative locations, which is the default. The packages are then
+// sent only when all the repositories have been searched, instead of as soon as they are found.
+func WithMergeMirrors(merge bool) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.mergeMirrors = merge
+	}
+}
+
+// WithMatchMode sets how the package names are matched, that is
+// as exact names (default), glob patterns or RE2 regular expressions.
+func WithMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.matchMode = mode
+	}
+}
+
+// WithMirrors sets the mirrors to search the packages in.
+func WithMirrors(mirrors ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.mirrors = mirrors
+	}
+}
+
+// WithSeeds sets the function that returns the URLs of the directories of the mirrors
+// that contain the release versions. By default, the release versions are in the mirror roots.
+func WithSeeds(seeds func(mirrors ...string) []string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.seeds = seeds
+	}
+}
+
+// WithVersionRegex sets the regular expression that the names of the
+// release version directories of the mirrors match.
+func WithVersionRegex(re string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.versionRegex = re
+	}
+}
+
+func WithSearchLogger(logger *log.Logger) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.logger = logger
+	}
+}
+
+func WithRepoTemplates(repos ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.repos = repos
+	}
+}
+
+// WithRepoVariables sets the values of the variables of the repository templates,
+// which are expanded besides the {{ .arch }} one.
+func WithRepoVariables(vars map[string][]string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.variables = vars
+	}
+}
+
+func WithArchs(archs ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.archs = archs
+	}
+}
+
+func WithAllRepos(reposAll bool) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.reposAll = reposAll
+	}
+}
+
+func WithDefaultRepos(reposDefault bool) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.reposDefault = reposDefault
+	}
+}
+
+// WithDefaultRepoTemplates sets the templates of the repositories of the release versions,
+// that are searched when no templates are set with WithRepoTemplates.
+func WithDefaultRepoTemplates(repos ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.defaultRepos = repos
+	}
+}
+
+// WithDefaultArchs sets the architectures of the repository templates,
+// that are searched when no architectures are set with WithArchs.
+func WithDefaultArchs(archs ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.defaultArchs = archs
+	}
+}
+
+// WithRootRepos sets the repositories, relative to the first mirror, that are searched
+// in addition to the ones of the release versions, such as the ones of a rolling release.
+func WithRootRepos(repos ...string) PackageSearchOption {
+	return func(search *PackageSearch) {
+		search.rootRepos = repos
+	}
+}
+
+func NewPackageSearch(o ...PackageSearchOption) *PackageSearch {
+	search := &PackageSearch{mergeMirrors: true, logger: log.New()}
+	for _, f := range o {
+		f(search)
+	}
+
+	return search
+}
+
+// Name returns the name of the distro.
+func (s *PackageSearch) Name() string {
+	return s.name
+}
+
+// Seeds returns the URLs of the mirror directories that contain the release versions.
+func (s *PackageSearch) Seeds() []string {
+	if s.seeds == nil {
+		return s.mirrors
+	}
+
+	return s.seeds(s.mirrors...)
+}
+
+// VersionStage returns the stage that searches the release versions in the mirrors.
+func (s *PackageSearch) VersionStage() packages.StageRunner {
+	return distro.NewVersionSearcher(
+		distro.WithVersionSearcherName(s.name),
+		distro.WithVersionSearcherRegex(s.versionRegex),
+		distro.WithVersionSearcherLogger(s.logger),
+	)
+}
+
+// RepoStage returns the stage that streams the repository metadata URLs of the release versions.
+func (s *PackageSearch) RepoStage() packages.StageRunner {
+	return packages.StageFunc(func(ctx context.Context, data chan string) chan string {
+		if s.reposAll {
+			data = rpm.NewRepoSearcher(rpm.WithRepoLogger(s.logger)).Run(ctx, data)
+		} else {
+			data = distro.StubStage(ctx, data, s.repoPaths())
+		}
+
+		// The root repositories are served by the first mirror only,
+		// as the others can archive the discontinued releases.
+		if len(s.rootRepos) > 0 && len(s.mirrors) > 0 {
+			root := packages.NewGenericProducer(
+				packages.WithSeeds(s.mirrors[0]),
+				packages.WithLogger(s.logger),
+			).Produce(ctx)
+			root = distro.StubStage(ctx, root, s.rootRepos)
+
+			data = packages.Merge(ctx, data, root)
+		}
+
+		return data
+	})
+}
+
+// repoPaths returns the paths of the repositories of the release versions, from the
+// templates expanded with the architectures. The templates and the architectures
+// fall back to the defaults independently.
+func (s *PackageSearch) repoPaths() []string {
+	templates, archs := s.defaultRepos, s.defaultArchs
+	if !s.reposDefault {
+		if len(s.repos) > 0 {
+			templates = s.repos
+		}
+		if len(s.archs) > 0 {
+			archs = s.archs
+		}
+	}
+
+	repos, err := template.NewMultiplexTemplate(
+		template.WithTemplates(templates...),
+		template.WithVariables(distro.RepoVariables(s.variables, archs)),
+	).Run()
+	if err != nil {
+		s.logger.WithError(err).Error("error executing the repository templates")
+	}
+
+	return repos
+}
+
+// PackageStage returns the stage that searches the packages in the repositories,
+// sending once the ones found in more mirrors and filtering them by version.
+func (s *PackageSearch) PackageStage() packages.SearchStageRunner {
+	return packages.SearchStageFunc(func(ctx context.Context, data chan string) chan *packages.Package {
+		pkgs := s.search(ctx, data)
+
+		// The same repositories are served by more mirrors.
+		pkgs = packages.NewDeduplicator(
+			packages.WithDeduplicatorMergeMirrors(s.mergeMirrors),
+			packages.WithDeduplicatorLogger(s.logger),
+		).Run(ctx, pkgs)
+
+		if s.latest || len(s.versions) > 0 {
+			pkgs = rpm.NewVersionFilter(
+				rpm.WithVersionConstraints(s.versions...),
+				rpm.WithVersionLatest(s.latest),
+				rpm.WithVersionLogger(s.logger),
+			).Run(ctx, pkgs)
+		}
+
+		return pkgs
+	})
+}
+
+// Search is a data streaming pipeline.
+func (s *PackageSearch) Search(ctx context.Context) chan *packages.Package {
+	return distro.Search(ctx, s, packages.WithLogger(s.logger))
+}
+
+// search runs the stage that searches the packages in the repositories, of which the
+// metadata URLs are streamed by data, by file path, by capability or by name.
+func (s *PackageSearch) search(ctx context.Context, data chan string) chan *packages.Package {
+	if len(s.filePaths) > 0 {
+		return rpm.NewFileSearcher(
+			rpm.WithFilePaths(s.filePaths...),
+			rpm.WithFileLogger(s.logger),
+		).Run(ctx, data)
+	}
+
+	if len(s.provides) > 0 || len(s.requires) > 0 {
+		return rpm.NewCapabilitySearcher(
+			rpm.WithCapabilityProvides(s.provides...),
+			rpm.WithCapabilityRequires(s.requires...),
+			rpm.WithCapabilityLogger(s.logger),
+		).Run(ctx, rpm.NewDBSearcher(rpm.WithDBLogger(s.logger)).Run(ctx, data))
+	}
+
+	// The packages are looked up in the SQLite databases, when the repositories provide them.
+	return rpm.NewPrimarySearcher(
+		rpm.WithPrimaryNames(s.names...),
+		rpm.WithPrimaryMatchMode(s.matchMode),
+		rpm.WithPrimaryLogger(s.logger),
+	).Run(ctx, data)
+}
+
+// Factory returns the factory of the distro with the default options, such as
+// its name, mirrors and repositories, which is configured with the options common
+// to the distros. The unset options fall back to the defaults.
+func Factory(defaults ...PackageSearchOption) distro.Factory {
+	return func(o *distro.Options) distro.Distro {
+		opts := append(append([]PackageSearchOption{}, defaults...),
+			WithPackageNames(o.Names...),
+			WithMatchMode(o.MatchMode),
+			WithFilePaths(o.FilePaths...),
+			WithProvides(o.Provides...),
+			WithRequires(o.Requires...),
+			WithVersions(o.Versions...),
+			WithLatest(o.Latest),
+			WithMergeMirrors(!o.StreamMirrors),
+			WithAllRepos(o.AllRepos),
+		)
+		if len(o.Mirrors) > 0 {
+			opts = append(opts, WithMirrors(o.Mirrors...))
+		}
+		if o.VersionRegex != "" {
+			opts = append(opts, WithVersionRegex(o.VersionRegex))
+		}
+		if len(o.Repos) > 0 {
+			opts = append(opts, WithRepoTemplates(o.Repos...))
+		}
+		if len(o.RepoVariables) > 0 {
+			opts = append(opts, WithRepoVariables(o.RepoVariables))
+		}
+		if len(o.Archs) > 0 {
+			opts = append(opts, WithArchs(o.Archs...))
+		}
+		if o.Logger != nil {
+			opts = append(opts, WithSearchLogger(o.Logger))
+		}
+
+		return NewPackageSearch(opts...)
+	}
+}
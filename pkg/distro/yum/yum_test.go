@@ -0,0 +1,101 @@
+//go:build all_tests || all_unit_tests || (unit_tests && yum)
+
+package yum_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/distro/yum"
+)
+
+const (
+	mirror  = "https://mirror.example.com/"
+	version = "https://mirror.example.com/9/"
+)
+
+// repos returns the repository metadata URLs streamed by the repository stage of the search
+// for a release version.
+func repos(search *yum.PackageSearch) []string {
+	source := make(chan string, 1)
+	source <- version
+	close(source)
+
+	var actual []string
+	for v := range search.RepoStage().Run(context.Background(), source) {
+		actual = append(actual, v)
+	}
+
+	return actual
+}
+
+var _ = Describe("Package search", func() {
+	defaults := []yum.PackageSearchOption{
+		yum.WithDistroName("fake"),
+		yum.WithMirrors(mirror),
+		yum.WithDefaultRepoTemplates("/BaseOS/{{ .arch }}/repodata/repomd.xml"),
+		yum.WithDefaultArchs("x86_64", "aarch64"),
+	}
+
+	Context("Seeds", func() {
+		It("Should seed the mirrors by default", func() {
+			search := yum.NewPackageSearch(defaults...)
+			Expect(search.Name()).To(Equal("fake"))
+			Expect(search.Seeds()).To(Equal([]string{mirror}))
+		})
+		It("Should seed the directories of the mirrors", func() {
+			search := yum.NewPackageSearch(append(defaults, yum.WithSeeds(func(mirrors ...string) []string {
+				return []string{mirrors[0] + "releases/"}
+			}))...)
+			Expect(search.Seeds()).To(Equal([]string{mirror + "releases/"}))
+		})
+	})
+
+	Context("Repositories", func() {
+		It("Should stream the default repositories", func() {
+			Expect(repos(yum.NewPackageSearch(defaults...))).To(ConsistOf(
+				version+"BaseOS/x86_64/repodata/repomd.xml",
+				version+"BaseOS/aarch64/repodata/repomd.xml",
+			))
+		})
+		It("Should default the templates and the architectures independently", func() {
+			Expect(repos(yum.NewPackageSearch(append(defaults, yum.WithArchs("ppc64le"))...))).To(ConsistOf(
+				version + "BaseOS/ppc64le/repodata/repomd.xml",
+			))
+			Expect(repos(yum.NewPackageSearch(append(defaults,
+				yum.WithRepoTemplates("/AppStream/{{ .arch }}/repodata/repomd.xml"))...),
+			)).To(ConsistOf(
+				version+"AppStream/x86_64/repodata/repomd.xml",
+				version+"AppStream/aarch64/repodata/repomd.xml",
+			))
+		})
+		It("Should expand the templates with the variables", func() {
+			Expect(repos(yum.NewPackageSearch(append(defaults,
+				yum.WithRepoTemplates("/{{ .sig }}/{{ .arch }}/repodata/repomd.xml"),
+				yum.WithRepoVariables(map[string][]string{"sig": {"kmods", "cloud"}}),
+				yum.WithArchs("x86_64"),
+			)...))).To(ConsistOf(
+				version+"kmods/x86_64/repodata/repomd.xml",
+				version+"cloud/x86_64/repodata/repomd.xml",
+			))
+		})
+		It("Should stream the default repositories when forced", func() {
+			Expect(repos(yum.NewPackageSearch(append(defaults,
+				yum.WithArchs("ppc64le"),
+				yum.WithDefaultRepos(true),
+			)...))).To(HaveLen(2))
+		})
+		It("Should stream the root repositories of the first mirror", func() {
+			Expect(repos(yum.NewPackageSearch(append(defaults,
+				yum.WithMirrors(mirror, "https://archive.example.com/"),
+				yum.WithRootRepos("tumbleweed/repodata/repomd.xml"),
+			)...))).To(ConsistOf(
+				version+"BaseOS/x86_64/repodata/repomd.xml",
+				version+"BaseOS/aarch64/repodata/repomd.xml",
+				mirror+"tumbleweed/repodata/repomd.xml",
+			))
+		})
+	})
+})
@@ -0,0 +1,17 @@
+package apk
+
+const (
+	FileIndex          = "APKINDEX"
+	FileIndexArchive   = "APKINDEX.tar.gz"
+	FieldName          = "P"
+	FieldVersion       = "V"
+	FieldArch          = "A"
+	FieldSize          = "S"
+	FieldInstalledSize = "I"
+	FieldChecksum      = "C"
+	FieldOrigin        = "o"
+	FieldBuildTime     = "t"
+	FieldLicense       = "L"
+	PackageExt         = ".apk"
+	fieldDelimiter     = ":"
+)
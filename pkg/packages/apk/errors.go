@@ -0,0 +1,10 @@
+package apk
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrIndexMissing             = errors.New("the index archive does not contain the index file")
+	ErrSearchPackageNameMissing = errors.New("at least one package name must be specified")
+)
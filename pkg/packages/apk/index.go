@@ -0,0 +1,49 @@
+package apk
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Record is a package record of an APKINDEX file.
+// Each field is in the form "<key>:<value>" on a single line,
+// and records are separated by an empty line.
+type Record map[string]string
+
+// RecordReader reads the records of an APKINDEX file, one at time.
+type RecordReader struct {
+	s *bufio.Scanner
+}
+
+func NewRecordReader(r io.Reader) *RecordReader {
+	return &RecordReader{s: bufio.NewScanner(r)}
+}
+
+// Read returns the next record. When no records are left, io.EOF is returned.
+func (rr *RecordReader) Read() (Record, error) {
+	record := Record{}
+
+	for rr.s.Scan() {
+		line := rr.s.Text()
+		if line == "" {
+			if len(record) > 0 {
+				return record, nil
+			}
+			continue
+		}
+
+		k, v, found := strings.Cut(line, fieldDelimiter)
+		if found {
+			record[k] = v
+		}
+	}
+	if err := rr.s.Err(); err != nil {
+		return nil, err
+	}
+	if len(record) > 0 {
+		return record, nil
+	}
+
+	return nil, io.EOF
+}
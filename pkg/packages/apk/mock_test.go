@@ -0,0 +1,107 @@
+//go:build all_tests || all_unit_tests || all_integration_tests || unit_tests || integration_tests
+
+package apk_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+)
+
+const (
+	mockIndexPath = "/alpine/v3.18/main/x86_64/APKINDEX.tar.gz"
+	// tarEndLength is the length of the two zero blocks that end a tar archive.
+	tarEndLength = 1024
+)
+
+type mockPackage struct {
+	name    string
+	version string
+	arch    string
+}
+
+var (
+	mockPackages = []mockPackage{
+		{name: "linux-lts", version: "6.1.58-r0", arch: "x86_64"},
+		{name: "linux-lts-dev", version: "6.1.58-r0", arch: "x86_64"},
+		{name: "linux-virt-dev", version: "6.1.58-r0", arch: "x86_64"},
+		{name: "musl", version: "1.2.4-r2", arch: "x86_64"},
+	}
+	mockRecordF = `C:Q1Zb9QCwDkyVBv3ue1NJs2m9X8lpw=
+P:%s
+V:%s
+A:%s
+S:1464833
+I:5394432
+T:Linux lts kernel
+U:https://www.kernel.org
+L:GPL-2.0-only
+o:linux-lts
+m:Natanael Copa <ncopa@alpinelinux.org>
+t:1697456788
+c:5b0b1d9e7d3b5a9a6a9f3c1e5b0b1d9e7d3b5a9a
+D:mkinitfs kmod
+
+`
+)
+
+// apkIndex returns an APKINDEX file that describes the specified packages.
+func apkIndex(pkgs ...mockPackage) string {
+	b := new(strings.Builder)
+	for _, p := range pkgs {
+		fmt.Fprintf(b, mockRecordF, p.name, p.version, p.arch)
+	}
+
+	return b.String()
+}
+
+func tarball(files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range files {
+		_ = tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+
+	return buf.Bytes()
+}
+
+func gzipped(b []byte) []byte {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	_, _ = w.Write(b)
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// indexArchive returns an index archive laid out like the ones built by abuild:
+// the signature tar archive, without its end blocks, concatenated to the index one,
+// each in a separate gzip stream.
+func indexArchive(pkgs ...mockPackage) []byte {
+	signature := tarball(map[string]string{".SIGN.RSA.alpine-devel@lists.alpinelinux.org-6165ee59.rsa.pub": "signature"})
+	signature = signature[:len(signature)-tarEndLength]
+	index := tarball(map[string]string{"DESCRIPTION": "v3.18.4-111-g2a9a3b5c3a", "APKINDEX": apkIndex(pkgs...)})
+
+	return append(gzipped(signature), gzipped(index)...)
+}
+
+// runMockMirror starts a mock server that serves the index of a repository.
+func runMockMirror() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(mockIndexPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(indexArchive(mockPackages...))
+	})
+
+	s := httptest.NewServer(mux)
+	DeferCleanup(s.Close)
+
+	return s
+}
@@ -0,0 +1,206 @@
+package apk
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"net/url"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/internal/compression"
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// Package is a package as described by a record of the APKINDEX file.
+type Package struct {
+	Name          string
+	Version       string
+	Arch          string
+	Size          string
+	InstalledSize string
+	// Checksum is the "Q1" prefixed base64 SHA1 digest of the package control segment.
+	Checksum  string
+	Origin    string
+	BuildTime string
+	License   string
+}
+
+func packageFromRecord(r Record) *Package {
+	return &Package{
+		Name:          r[FieldName],
+		Version:       r[FieldVersion],
+		Arch:          r[FieldArch],
+		Size:          r[FieldSize],
+		InstalledSize: r[FieldInstalledSize],
+		Checksum:      r[FieldChecksum],
+		Origin:        r[FieldOrigin],
+		BuildTime:     r[FieldBuildTime],
+		License:       r[FieldLicense],
+	}
+}
+
+// Filename returns the name of the package file in the repository.
+func (p *Package) Filename() string {
+	return p.Name + "-" + p.Version + PackageExt
+}
+
+type PackageSearch struct {
+	names     []string
+	matchMode packages.MatchMode
+	logger    *log.Logger
+}
+
+type PackageSearchOption func(s *PackageSearch)
+
+func WithPackageNames(names ...string) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.names = names
+	}
+}
+
+// WithPackageMatchMode sets how the names are matched against the names of the packages.
+// The names can be exact names (default), glob patterns or RE2 regular expressions.
+func WithPackageMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.matchMode = mode
+	}
+}
+
+func WithPackageLogger(logger *log.Logger) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.logger = logger
+	}
+}
+
+func NewPackageSearcher(o ...PackageSearchOption) *PackageSearch {
+	ps := &PackageSearch{logger: log.New()}
+	for _, f := range o {
+		f(ps)
+	}
+
+	return ps
+}
+
+func (ps *PackageSearch) validate() error {
+	if len(ps.names) == 0 {
+		return ErrSearchPackageNameMissing
+	}
+
+	_, err := packages.NewNameMatcher(ps.matchMode, ps.names...)
+
+	return err
+}
+
+// Run runs a pipeline stage of which the output is a channel of the packages
+// that match the searched names.
+// The source of the stage is a channel of APKINDEX.tar.gz URL strings.
+func (ps *PackageSearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
+	destCh := make(chan *packages.Package)
+	if err := ps.validate(); err != nil {
+		ps.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "apk.PackageSearch", sourceCh, err)
+		return destCh
+	}
+
+	matcher, _ := packages.NewNameMatcher(ps.matchMode, ps.names...)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			ps.logger.WithField("index", source).Debug("receive")
+			// The packages are in the same directory of the index.
+			u, err := url.Parse(source)
+			if err != nil {
+				packages.ReportError(ctx, "apk.PackageSearch", source, err)
+				return
+			}
+			u.Path = path.Dir(u.Path)
+
+			pkgs, err := ps.packagesFromIndex(ctx, source, matcher)
+			if err != nil {
+				ps.logger.WithError(err).WithField("index", source).Debug("error reading index")
+				packages.ReportError(ctx, "apk.PackageSearch", source, err)
+				return
+			}
+
+			for _, pkg := range pkgs {
+				pkgURL, err := url.JoinPath(u.String(), pkg.Filename())
+				if err != nil {
+					continue
+				}
+				query, _ := matcher.Match(pkg.Name)
+				ps.logger.WithField("package", pkgURL).Debug("send")
+				p := packages.NewPackage(
+					packages.WithName(pkg.Name),
+					packages.WithQuery(query),
+					packages.WithVersion(pkg.Version),
+					packages.WithLocation(pkgURL),
+					packages.WithArchitecture(pkg.Arch),
+				)
+				if !packages.Send(ctx, destCh, p) {
+					return
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+// packagesFromIndex streams the index archive and returns the packages with the searched names.
+// The archive is the concatenation of the signature and the index gzip streams,
+// that are read as a single tar archive.
+func (ps *PackageSearch) packagesFromIndex(ctx context.Context, indexURL string, matcher *packages.NameMatcher) ([]*Package, error) {
+	resp, err := network.Get(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r, err := compression.NewReader(resp.Body, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrIndexMissing
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if h.Name == FileIndex {
+			return ps.packagesFromRecords(tr, matcher)
+		}
+	}
+}
+
+func (ps *PackageSearch) packagesFromRecords(r io.Reader, matcher *packages.NameMatcher) ([]*Package, error) {
+	var pkgs []*Package
+
+	rr := NewRecordReader(r)
+	for {
+		record, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := matcher.Match(record[FieldName]); ok {
+			pkgs = append(pkgs, packageFromRecord(record))
+		}
+	}
+
+	return pkgs, nil
+}
@@ -0,0 +1,133 @@
+//go:build all_tests || all_unit_tests || (unit_tests && packages && apk)
+
+package apk_test
+
+import (
+	"context"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/apk"
+)
+
+var _ = Describe("Packages search", func() {
+	var (
+		search *apk.PackageSearch
+		ctx    = context.Background()
+	)
+
+	Context("With names", func() {
+		BeforeEach(func() {
+			search = apk.NewPackageSearcher(
+				apk.WithPackageNames("linux-lts-dev", "linux-virt-dev"),
+			)
+		})
+		Context("with seed URLs", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan *packages.Package)
+				actual   []*packages.Package
+				repo     string
+			)
+			BeforeAll(func() {
+				s := runMockMirror()
+				repo = s.URL + path.Dir(mockIndexPath)
+
+				// Test producer.
+				go func() {
+					sourceCh <- s.URL + mockIndexPath
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stage results", func() {
+				Expect(actual).To(HaveLen(2))
+			})
+			It("Should stage the package metadata", func() {
+				for _, v := range actual {
+					Expect(v.Describe()).To(BeElementOf("linux-lts-dev", "linux-virt-dev"))
+					Expect(v.Version()).To(Equal("6.1.58-r0"))
+					Expect(v.Architecture()).To(Equal("x86_64"))
+				}
+			})
+			It("Should stage the package location in the repository", func() {
+				for _, v := range actual {
+					Expect(v.Locate()).To(Equal(repo + "/" + v.Describe() + "-6.1.58-r0.apk"))
+				}
+			})
+		})
+		Context("with seed URLs closed channel", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan *packages.Package)
+				res      []*packages.Package
+			)
+			BeforeAll(func() {
+				// Noop test producer.
+				go func() {
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					res = append(res, v)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should not stage results", func() {
+				Expect(res).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("With name patterns", Ordered, func() {
+		var (
+			sourceCh = make(chan string)
+			actual   []*packages.Package
+		)
+		BeforeAll(func() {
+			search = apk.NewPackageSearcher(
+				apk.WithPackageNames("linux-[a-z]+-dev"),
+				apk.WithPackageMatchMode(packages.MatchRegex),
+			)
+			s := runMockMirror()
+
+			// Test producer.
+			go func() {
+				sourceCh <- s.URL + mockIndexPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range search.Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+		})
+		It("Should stage the packages matching the patterns", func() {
+			Expect(actual).To(HaveLen(2))
+		})
+		It("Should stage the pattern each result matched", func() {
+			for _, v := range actual {
+				Expect(v.Query()).To(Equal("linux-[a-z]+-dev"))
+			}
+		})
+	})
+})
@@ -0,0 +1,29 @@
+package deb
+
+const (
+	DirDists      = "dists"
+	FileRelease   = "Release"
+	FileInRelease = "InRelease"
+	FilePackages  = "Packages"
+	FieldPackage  = "Package"
+	FieldVersion  = "Version"
+	FieldArch     = "Architecture"
+	FieldFilename = "Filename"
+	FieldSize     = "Size"
+	FieldSHA256   = "SHA256"
+	FieldSHA1     = "SHA1"
+	FieldMD5Sum   = "MD5Sum"
+	FieldSource   = "Source"
+	// SuiteRegex matches the suite directory names, like bookworm, jammy-updates.
+	SuiteRegex = `^[a-z]+(-[a-z]+)*\/?$`
+)
+
+var (
+	// checksumFields are the Release fields that list the index files,
+	// in order of preference.
+	checksumFields = []string{FieldSHA256, FieldSHA1, FieldMD5Sum}
+
+	// indexExts are the extensions of the Packages indexes,
+	// in order of preference.
+	indexExts = []string{".xz", ".gz", ""}
+)
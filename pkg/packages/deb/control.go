@@ -0,0 +1,103 @@
+package deb
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+const (
+	pgpSignedMessage = "-----BEGIN PGP SIGNED MESSAGE-----"
+	pgpSignature     = "-----BEGIN PGP SIGNATURE-----"
+)
+
+// Paragraph is a stanza of a Debian control file, like the Release file and the Packages indexes.
+// Multiline field values are joined by new lines, with the leading whitespaces stripped.
+type Paragraph map[string]string
+
+// ParagraphReader reads the paragraphs of a Debian control file, one at time.
+// Clear-signed files, like InRelease, are supported by skipping the OpenPGP armor.
+type ParagraphReader struct {
+	r      *bufio.Reader
+	signed bool
+	eof    bool
+}
+
+func NewParagraphReader(r io.Reader) *ParagraphReader {
+	return &ParagraphReader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next paragraph. When no paragraphs are left, io.EOF is returned.
+func (p *ParagraphReader) Read() (Paragraph, error) {
+	if p.eof {
+		return nil, io.EOF
+	}
+
+	paragraph := Paragraph{}
+	key := ""
+
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == io.EOF {
+			p.eof = true
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == pgpSignedMessage:
+			// Skip the armor headers, that end with an empty line.
+			p.signed = true
+			if err := p.skipArmorHeaders(); err != nil {
+				return nil, err
+			}
+		case p.signed && line == pgpSignature:
+			// Nothing is left to be read after the signature.
+			p.eof = true
+		case strings.TrimSpace(line) == "":
+			if len(paragraph) > 0 {
+				return paragraph, nil
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			if key != "" {
+				continuation := strings.TrimSpace(line)
+				if paragraph[key] == "" {
+					paragraph[key] = continuation
+				} else {
+					paragraph[key] += "\n" + continuation
+				}
+			}
+		default:
+			k, v, found := strings.Cut(line, ":")
+			if found {
+				key = k
+				paragraph[key] = strings.TrimSpace(v)
+			}
+		}
+
+		if p.eof {
+			if len(paragraph) > 0 {
+				return paragraph, nil
+			}
+			return nil, io.EOF
+		}
+	}
+}
+
+func (p *ParagraphReader) skipArmorHeaders() error {
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				p.eof = true
+				return nil
+			}
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package deb
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrReleaseFilesMissing      = errors.New("the release file does not list any index file")
+	ErrSearchPackageNameMissing = errors.New("at least one package name must be specified")
+	ErrIndexURLMalformed        = errors.New("the index URL is not under the dists directory of an archive")
+)
@@ -0,0 +1,161 @@
+//go:build all_tests || all_unit_tests || all_integration_tests || unit_tests || integration_tests
+
+package deb_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	mockSuite          = "bookworm"
+	mockSuitePath      = "/debian/dists/" + mockSuite + "/"
+	mockReleasePath    = mockSuitePath + "Release"
+	mockInReleasePath  = mockSuitePath + "InRelease"
+	mockIndexAmd64Path = mockSuitePath + "main/binary-amd64/Packages.xz"
+	mockIndexArm64Path = mockSuitePath + "main/binary-arm64/Packages.gz"
+)
+
+type mockPackage struct {
+	name    string
+	version string
+	arch    string
+}
+
+var (
+	mockPackagesAmd64 = []mockPackage{
+		{name: "linux-headers-6.1.0-13-amd64", version: "6.1.55-1", arch: "amd64"},
+		{name: "linux-headers-6.1.0-13-common", version: "6.1.55-1", arch: "all"},
+		{name: "vim-common", version: "2:9.0.1378-2", arch: "all"},
+	}
+	mockPackagesArm64 = []mockPackage{
+		{name: "linux-headers-6.1.0-13-arm64", version: "6.1.55-1", arch: "arm64"},
+		{name: "linux-headers-6.1.0-13-common", version: "6.1.55-1", arch: "all"},
+	}
+	mockRelease = `Origin: Debian
+Label: Debian
+Suite: stable
+Codename: bookworm
+Architectures: amd64 arm64
+Components: main
+Description: Debian 12.2 Released 07 October 2023
+MD5Sum:
+ 0ed6d4c8891eb86358b94bb35d9e4da4  1484322 contrib/Contents-all
+ d0a0325a97c42fd5f66a8c3e29bcea64    98581 contrib/Contents-all.gz
+SHA256:
+ 3957f28db16e3f28c7b34ae84f1c929c567de6970f3f1b95dac9b498dd80fe63   738242 main/binary-amd64/Packages
+ 3e9a121d599b56c08bc8f144e4830807c77c29d7114316d6984ba54695d3db7b   57319 main/binary-amd64/Packages.gz
+ 2a3c5d09a3d4a1f1d3c1c6e3a0f0b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2   43240 main/binary-amd64/Packages.xz
+ 1a3c5d09a3d4a1f1d3c1c6e3a0f0b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2      120 main/binary-amd64/Release
+ 4a3c5d09a3d4a1f1d3c1c6e3a0f0b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2   57319 main/binary-arm64/Packages.gz
+ 5a3c5d09a3d4a1f1d3c1c6e3a0f0b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2   57319 main/debian-installer/binary-amd64/Packages.gz
+ 6a3c5d09a3d4a1f1d3c1c6e3a0f0b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2   57319 main/i18n/Translation-en.bz2
+`
+	mockInRelease = `-----BEGIN PGP SIGNED MESSAGE-----
+Hash: SHA256
+
+` + mockRelease + `-----BEGIN PGP SIGNATURE-----
+
+iQIzBAEBCAAdFiEEpyNohvPMyq0Uiif4DphATThvodkFAmUhRzEACgkQDphATThv
+=E5Jh
+-----END PGP SIGNATURE-----
+`
+	mockDistsBody = fmt.Sprintf(`
+<html>
+<head><title>Index of /debian/dists/</title></head>
+<body>
+<h1>Index of /debian/dists/</h1><hr><pre><a href="../">../</a>
+<a href="%s/">%s/</a>
+<a href="%s-updates/">%s-updates/</a>
+<a href="stable/">stable/</a>
+<a href="stable-updates/">stable-updates/</a>
+<a href="README">README</a>
+</pre><hr></body>
+</html>
+`, mockSuite, mockSuite, mockSuite, mockSuite)
+)
+
+// packagesIndex returns a Packages index that describes the specified packages.
+func packagesIndex(pkgs ...mockPackage) string {
+	b := new(strings.Builder)
+	for _, p := range pkgs {
+		fmt.Fprintf(b, `Package: %s
+Source: linux
+Version: %s
+Installed-Size: 12345
+Maintainer: Debian Kernel Team <debian-kernel@lists.debian.org>
+Architecture: %s
+Description: Header files for Linux
+ This package provides the architecture-specific kernel header files
+ .
+ for Linux kernel.
+Section: kernel
+Priority: optional
+Filename: pool/main/l/linux/%s_%s_%s.deb
+Size: 1234567
+SHA256: 2a3c5d09a3d4a1f1d3c1c6e3a0f0b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2
+
+`, p.name, p.version, p.arch, p.name, p.version, p.arch)
+	}
+
+	return b.String()
+}
+
+func gzipped(s string) []byte {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func xzipped(s string) []byte {
+	buf := new(bytes.Buffer)
+	w, _ := xz.NewWriter(buf)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// runMockArchive starts a mock server that serves a Debian archive with a single suite,
+// but the files at the excluded paths.
+func runMockArchive(excluded ...string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debian/dists/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(mockDistsBody))
+	})
+	mux.HandleFunc(mockReleasePath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(mockRelease))
+	})
+	mux.HandleFunc(mockInReleasePath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(mockInRelease))
+	})
+	mux.HandleFunc(mockIndexAmd64Path, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(xzipped(packagesIndex(mockPackagesAmd64...)))
+	})
+	mux.HandleFunc(mockIndexArm64Path, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(gzipped(packagesIndex(mockPackagesArm64...)))
+	})
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, v := range excluded {
+			if r.URL.Path == v {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	DeferCleanup(s.Close)
+
+	return s
+}
@@ -0,0 +1,191 @@
+package deb
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/internal/compression"
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// Package is a binary package as described by a paragraph of a Packages index.
+type Package struct {
+	Name         string
+	Version      string
+	Architecture string
+	Filename     string
+	Size         string
+	SHA256       string
+	Source       string
+}
+
+func packageFromParagraph(p Paragraph) *Package {
+	return &Package{
+		Name:         p[FieldPackage],
+		Version:      p[FieldVersion],
+		Architecture: p[FieldArch],
+		Filename:     p[FieldFilename],
+		Size:         p[FieldSize],
+		SHA256:       p[FieldSHA256],
+		Source:       p[FieldSource],
+	}
+}
+
+type PackageSearch struct {
+	names     []string
+	matchMode packages.MatchMode
+	logger    *log.Logger
+}
+
+type PackageSearchOption func(s *PackageSearch)
+
+func WithPackageNames(names ...string) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.names = names
+	}
+}
+
+// WithPackageMatchMode sets how the names are matched against the names of the packages.
+// The names can be exact names (default), glob patterns or RE2 regular expressions.
+func WithPackageMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.matchMode = mode
+	}
+}
+
+func WithPackageLogger(logger *log.Logger) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.logger = logger
+	}
+}
+
+func NewPackageSearcher(o ...PackageSearchOption) *PackageSearch {
+	ps := &PackageSearch{logger: log.New()}
+	for _, f := range o {
+		f(ps)
+	}
+
+	return ps
+}
+
+func (ps *PackageSearch) validate() error {
+	if len(ps.names) == 0 {
+		return ErrSearchPackageNameMissing
+	}
+
+	_, err := packages.NewNameMatcher(ps.matchMode, ps.names...)
+
+	return err
+}
+
+// Run runs a pipeline stage of which the output is a channel of the packages
+// that match the searched names.
+// The source of the stage is a channel of Packages index URL strings.
+func (ps *PackageSearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
+	destCh := make(chan *packages.Package)
+	if err := ps.validate(); err != nil {
+		ps.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "deb.PackageSearch", sourceCh, err)
+		return destCh
+	}
+
+	matcher, _ := packages.NewNameMatcher(ps.matchMode, ps.names...)
+
+	// The packages of all the architectures are listed by the indexes of each one,
+	// so they are sent once per location.
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+	)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			ps.logger.WithField("index", source).Debug("receive")
+			// The archive root is the base of the Filename field of the packages.
+			root, _, found := strings.Cut(source, "/"+DirDists+"/")
+			if !found {
+				ps.logger.WithField("index", source).Debug("index out of the dists directory")
+				packages.ReportError(ctx, "deb.PackageSearch", source, ErrIndexURLMalformed)
+				return
+			}
+
+			pkgs, err := ps.packagesFromIndex(ctx, source, matcher)
+			if err != nil {
+				ps.logger.WithError(err).WithField("index", source).Debug("error reading index")
+				packages.ReportError(ctx, "deb.PackageSearch", source, err)
+				return
+			}
+
+			for _, pkg := range pkgs {
+				pkgURL, err := url.JoinPath(root, pkg.Filename)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				dup := seen[pkgURL]
+				seen[pkgURL] = true
+				mu.Unlock()
+				if dup {
+					continue
+				}
+				query, _ := matcher.Match(pkg.Name)
+				ps.logger.WithField("package", pkgURL).Debug("send")
+				p := packages.NewPackage(
+					packages.WithName(pkg.Name),
+					packages.WithQuery(query),
+					packages.WithVersion(pkg.Version),
+					packages.WithLocation(pkgURL),
+					packages.WithArchitecture(pkg.Architecture),
+				)
+				if !packages.Send(ctx, destCh, p) {
+					return
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+// packagesFromIndex streams the Packages index and returns the packages with the searched names.
+func (ps *PackageSearch) packagesFromIndex(ctx context.Context, indexURL string, matcher *packages.NameMatcher) ([]*Package, error) {
+	resp, err := network.Get(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r, err := compression.NewReader(resp.Body, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var pkgs []*Package
+
+	pr := NewParagraphReader(r)
+	for {
+		p, err := pr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := matcher.Match(p[FieldPackage]); ok {
+			pkgs = append(pkgs, packageFromParagraph(p))
+		}
+	}
+
+	return pkgs, nil
+}
@@ -0,0 +1,164 @@
+//go:build all_tests || all_unit_tests || (unit_tests && packages && deb)
+
+package deb_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/deb"
+)
+
+var _ = Describe("Packages search", func() {
+	var (
+		search *deb.PackageSearch
+		ctx    = context.Background()
+	)
+
+	Context("With names", func() {
+		BeforeEach(func() {
+			search = deb.NewPackageSearcher(
+				deb.WithPackageNames("linux-headers-6.1.0-13-common", "linux-headers-6.1.0-13-amd64"),
+			)
+		})
+		Context("with seed URLs", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan *packages.Package)
+				actual   []*packages.Package
+				root     string
+			)
+			BeforeAll(func() {
+				s := runMockArchive()
+				root = s.URL + "/debian/"
+
+				// Test producer.
+				go func() {
+					sourceCh <- s.URL + mockIndexAmd64Path
+					sourceCh <- s.URL + mockIndexArm64Path
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stage results from all the indexes", func() {
+				Expect(actual).To(HaveLen(2))
+			})
+			It("Should stage the packages of all the architectures once", func() {
+				locations := []string{}
+				for _, v := range actual {
+					locations = append(locations, v.Locate())
+				}
+				Expect(locations).To(ConsistOf(
+					root+"pool/main/l/linux/linux-headers-6.1.0-13-amd64_6.1.55-1_amd64.deb",
+					root+"pool/main/l/linux/linux-headers-6.1.0-13-common_6.1.55-1_all.deb",
+				))
+			})
+			It("Should stage the package metadata", func() {
+				for _, v := range actual {
+					Expect(v.Version()).To(Equal("6.1.55-1"))
+					Expect(v.Architecture()).To(BeElementOf("amd64", "all"))
+					Expect(v.Query()).To(Equal(v.Describe()))
+				}
+			})
+			It("Should stage the pool location", func() {
+				for _, v := range actual {
+					Expect(v.Locate()).To(HavePrefix(root + "pool/main/l/linux/" + v.Describe() + "_"))
+					Expect(v.Locate()).To(HaveSuffix(".deb"))
+				}
+			})
+		})
+		Context("with seed URLs closed channel", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan *packages.Package)
+				res      []*packages.Package
+			)
+			BeforeAll(func() {
+				// Noop test producer.
+				go func() {
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					res = append(res, v)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should not stage results", func() {
+				Expect(res).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("With name patterns", Ordered, func() {
+		var (
+			sourceCh = make(chan string)
+			actual   []*packages.Package
+		)
+		BeforeAll(func() {
+			search = deb.NewPackageSearcher(
+				deb.WithPackageNames("linux-headers-*-common"),
+				deb.WithPackageMatchMode(packages.MatchGlob),
+			)
+			s := runMockArchive()
+
+			// Test producer.
+			go func() {
+				sourceCh <- s.URL + mockIndexAmd64Path
+				sourceCh <- s.URL + mockIndexArm64Path
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range search.Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+		})
+		It("Should stage the packages matching the patterns", func() {
+			Expect(actual).To(HaveLen(1))
+		})
+		It("Should stage the pattern each result matched", func() {
+			for _, v := range actual {
+				Expect(v.Query()).To(Equal("linux-headers-*-common"))
+			}
+		})
+	})
+
+	Context("With index URLs out of the dists directory", func() {
+		It("Should report them", func() {
+			c := packages.NewErrorCollector()
+			ctx := packages.WithErrorCollector(ctx, c)
+			s := runMockArchive()
+
+			sourceCh := make(chan string, 1)
+			sourceCh <- s.URL + "/debian/main/binary-amd64/Packages.xz"
+			close(sourceCh)
+
+			search = deb.NewPackageSearcher(deb.WithPackageNames("linux-headers-6.1.0-13-amd64"))
+			Eventually(search.Run(ctx, sourceCh)).Should(BeClosed())
+
+			Expect(c.Errors()).To(HaveLen(1))
+			Expect(c.Errors()[0].Stage).To(Equal("deb.PackageSearch"))
+			Expect(c.Errors()[0]).To(MatchError(deb.ErrIndexURLMalformed))
+		})
+	})
+})
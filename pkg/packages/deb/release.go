@@ -0,0 +1,215 @@
+package deb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+var indexPathRegex = regexp.MustCompile(`^(?P<component>[^/]+)/binary-(?P<arch>[^/]+)/` + FilePackages + `(\.[a-z]+)?$`)
+
+type IndexSearch struct {
+	archs      []string
+	components []string
+	logger     *log.Logger
+}
+
+type IndexSearchOption func(s *IndexSearch)
+
+// WithIndexArchs restricts the search to the indexes of the specified architectures.
+func WithIndexArchs(archs ...string) IndexSearchOption {
+	return func(search *IndexSearch) {
+		search.archs = archs
+	}
+}
+
+// WithIndexComponents restricts the search to the indexes of the specified components.
+func WithIndexComponents(components ...string) IndexSearchOption {
+	return func(search *IndexSearch) {
+		search.components = components
+	}
+}
+
+func WithIndexLogger(logger *log.Logger) IndexSearchOption {
+	return func(search *IndexSearch) {
+		search.logger = logger
+	}
+}
+
+func NewIndexSearcher(o ...IndexSearchOption) *IndexSearch {
+	is := &IndexSearch{logger: log.New()}
+	for _, f := range o {
+		f(is)
+	}
+
+	return is
+}
+
+// Run runs a pipeline stage of which the output is a channel of Packages index URL strings.
+// The source of the stage is a channel of Release or InRelease file URL strings.
+// For each index, only the URL of the most compressed variant listed in the release file is sent.
+func (is *IndexSearch) Run(ctx context.Context, sourceCh chan string) chan string {
+	destCh := make(chan string)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			is.logger.WithField("release", source).Debug("receive")
+			u, err := url.Parse(source)
+			if err != nil {
+				packages.ReportError(ctx, "deb.IndexSearch", source, err)
+				return
+			}
+			u.Path = path.Dir(u.Path)
+
+			files, err := getIndexFilesFromReleaseURL(ctx, source)
+			if err != nil {
+				is.logger.WithError(err).WithField("release", source).Debug("error reading release")
+				packages.ReportError(ctx, "deb.IndexSearch", source, err)
+				return
+			}
+
+			for _, v := range is.selectIndexes(files) {
+				if u, err := url.JoinPath(u.String(), v); err == nil {
+					is.logger.WithField("index", u).Debug("send")
+					if !packages.Send(ctx, destCh, u) {
+						return
+					}
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+// selectIndexes returns the paths of the Packages indexes that match the architectures
+// and the components of the search, choosing for each the preferred compression.
+func (is *IndexSearch) selectIndexes(files []string) []string {
+	available := make(map[string]bool, len(files))
+	for _, v := range files {
+		available[v] = true
+	}
+
+	var indexes []string
+	seen := make(map[string]bool)
+
+	for _, v := range files {
+		m := indexPathRegex.FindStringSubmatch(v)
+		if m == nil {
+			continue
+		}
+		component := m[indexPathRegex.SubexpIndex("component")]
+		arch := m[indexPathRegex.SubexpIndex("arch")]
+		if !contains(is.components, component) || !contains(is.archs, arch) {
+			continue
+		}
+
+		base := path.Join(component, "binary-"+arch, FilePackages)
+		if seen[base] {
+			continue
+		}
+		seen[base] = true
+
+		for _, ext := range indexExts {
+			if available[base+ext] {
+				indexes = append(indexes, base+ext)
+				break
+			}
+		}
+	}
+
+	return indexes
+}
+
+// getIndexFilesFromReleaseURL returns the paths of the files listed by the release file,
+// relative to the release file directory. When the release file is not found, the other
+// release file of the suite is read instead, as many mirrors serve only the InRelease one.
+func getIndexFilesFromReleaseURL(ctx context.Context, releaseURL string) ([]string, error) {
+	files, err := indexFilesFromReleaseURL(ctx, releaseURL)
+
+	var statusErr *network.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		if alt, ok := alternativeReleaseURL(releaseURL); ok {
+			return indexFilesFromReleaseURL(ctx, alt)
+		}
+	}
+
+	return files, err
+}
+
+// alternativeReleaseURL returns the URL of the InRelease file of the suite of the Release file,
+// and vice versa.
+func alternativeReleaseURL(releaseURL string) (string, bool) {
+	dir, file := path.Split(releaseURL)
+	switch file {
+	case FileRelease:
+		return dir + FileInRelease, true
+	case FileInRelease:
+		return dir + FileRelease, true
+	default:
+		return "", false
+	}
+}
+
+func indexFilesFromReleaseURL(ctx context.Context, releaseURL string) ([]string, error) {
+	resp, err := network.Get(ctx, releaseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return indexFilesFromRelease(resp.Body)
+}
+
+func indexFilesFromRelease(r io.Reader) ([]string, error) {
+	release, err := NewParagraphReader(r).Read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range checksumFields {
+		entries, ok := release[field]
+		if !ok {
+			continue
+		}
+
+		var files []string
+		for _, line := range strings.Split(entries, "\n") {
+			// Each entry is in the form "<checksum> <size> <path>".
+			if fields := strings.Fields(line); len(fields) == 3 {
+				files = append(files, fields[2])
+			}
+		}
+
+		return files, nil
+	}
+
+	return nil, ErrReleaseFilesMissing
+}
+
+// contains returns whether the value is in the list, or whether the list is empty.
+func contains(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
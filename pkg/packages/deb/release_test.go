@@ -0,0 +1,125 @@
+//go:build all_tests || all_unit_tests || (unit_tests && release && deb)
+
+package deb_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages/deb"
+)
+
+var _ = Describe("Index search", func() {
+	var (
+		search *deb.IndexSearch
+		ctx    = context.Background()
+	)
+
+	for _, file := range []string{mockReleasePath, mockInReleasePath} {
+		file := file
+		Context("With release file "+file, func() {
+			BeforeEach(func() {
+				search = deb.NewIndexSearcher()
+			})
+			Context("with seed URLs", Ordered, func() {
+				var (
+					sourceCh = make(chan string)
+					destCh   = make(chan string)
+					actual   []string
+					expected []string
+				)
+				BeforeAll(func() {
+					s := runMockArchive()
+
+					// Test producer.
+					go func() {
+						sourceCh <- s.URL + file
+						close(sourceCh)
+					}()
+
+					// Stage.
+					destCh = search.Run(ctx, sourceCh)
+
+					// Test sink.
+					for v := range destCh {
+						actual = append(actual, v)
+					}
+
+					// Expected data.
+					expected = []string{
+						s.URL + mockIndexAmd64Path,
+						s.URL + mockIndexArm64Path,
+					}
+				})
+				It("Should not fail", func() {
+					Expect(destCh).ToNot(BeNil())
+				})
+				It("Should stage the most compressed binary package indexes", func() {
+					Expect(actual).To(ConsistOf(expected))
+				})
+			})
+		})
+	}
+
+	Context("With a mirror that serves only the InRelease file", Ordered, func() {
+		var (
+			sourceCh = make(chan string)
+			actual   []string
+			expected []string
+		)
+		BeforeAll(func() {
+			s := runMockArchive(mockReleasePath)
+			search = deb.NewIndexSearcher()
+
+			// Test producer.
+			go func() {
+				sourceCh <- s.URL + mockReleasePath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range search.Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			expected = []string{
+				s.URL + mockIndexAmd64Path,
+				s.URL + mockIndexArm64Path,
+			}
+		})
+		It("Should fall back to the InRelease file", func() {
+			Expect(actual).To(ConsistOf(expected))
+		})
+	})
+
+	Context("With architectures", Ordered, func() {
+		var (
+			sourceCh = make(chan string)
+			actual   []string
+		)
+		BeforeAll(func() {
+			s := runMockArchive()
+			search = deb.NewIndexSearcher(
+				deb.WithIndexArchs("arm64"),
+				deb.WithIndexComponents("main"),
+			)
+
+			// Test producer.
+			go func() {
+				sourceCh <- s.URL + mockReleasePath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range search.Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+		})
+		It("Should stage only the indexes of the architectures", func() {
+			Expect(actual).To(HaveLen(1))
+			Expect(actual[0]).To(HaveSuffix(mockIndexArm64Path))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package deb
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	wfind "github.com/maxgio92/wfind/pkg/find"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+type SuiteSearcher struct {
+	excluded []string
+	logger   *log.Logger
+}
+
+type SuiteSearchOption func(s *SuiteSearcher)
+
+// WithExcludedSuites excludes suites by name, like the symbolic links to codenames
+// (e.g. stable, testing) that would lead to search the same suites twice.
+// Suites that are named after an excluded one with a suffix (e.g. stable-updates) are excluded as well.
+func WithExcludedSuites(suites ...string) SuiteSearchOption {
+	return func(search *SuiteSearcher) {
+		search.excluded = suites
+	}
+}
+
+func WithSuiteLogger(logger *log.Logger) SuiteSearchOption {
+	return func(search *SuiteSearcher) {
+		search.logger = logger
+	}
+}
+
+func NewSuiteSearcher(o ...SuiteSearchOption) *SuiteSearcher {
+	ss := &SuiteSearcher{logger: log.New()}
+	for _, f := range o {
+		f(ss)
+	}
+
+	return ss
+}
+
+// Run runs a pipeline stage of which the output is a channel of suite directory URL strings.
+// The source of the stage is a channel of archive root URL strings, like the ones of
+// the mirrors, that contain the dists directory.
+func (ss *SuiteSearcher) Run(ctx context.Context, sourceCh chan string) chan string {
+	destCh := make(chan string)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			ss.logger.WithField("mirror", source).Debug("receive")
+			dists, err := url.JoinPath(source, DirDists+"/")
+			if err != nil {
+				packages.ReportError(ctx, "deb.SuiteSearcher", source, err)
+				return
+			}
+
+			finder := wfind.NewFind(
+				wfind.WithSeedURLs([]string{dists}),
+				wfind.WithFilenameRegexp(SuiteRegex),
+				wfind.WithFileType(wfind.FileTypeDir),
+				wfind.WithRecursive(false),
+				wfind.WithAsync(true),
+				wfind.WithContextDeadlineRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+				wfind.WithConnResetRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+				wfind.WithConnTimeoutRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+				wfind.WithClientTransport(network.FindTransport(ctx)),
+			)
+
+			found, err := network.Find(ctx, finder)
+			if err != nil {
+				ss.logger.WithError(err).Debug("error searching suites")
+				packages.ReportError(ctx, "deb.SuiteSearcher", source, err)
+			}
+			if found != nil {
+				for _, v := range found.URLs {
+					v := v
+					if ss.isExcluded(v) {
+						continue
+					}
+					ss.logger.WithField("suite", v).Debug("send")
+					if !packages.Send(ctx, destCh, v) {
+						return
+					}
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+func (ss *SuiteSearcher) isExcluded(suiteURL string) bool {
+	name := path.Base(strings.TrimSuffix(suiteURL, "/"))
+	for _, v := range ss.excluded {
+		if name == v || strings.HasPrefix(name, v+"-") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+//go:build all_tests || all_unit_tests || (unit_tests && suite && deb)
+
+package deb_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages/deb"
+)
+
+var _ = Describe("Suite search", func() {
+	var (
+		search *deb.SuiteSearcher
+		ctx    = context.Background()
+	)
+
+	Context("With excluded suites", func() {
+		BeforeEach(func() {
+			search = deb.NewSuiteSearcher(deb.WithExcludedSuites("stable"))
+		})
+		Context("with seed URLs", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan string)
+				actual   []string
+				expected []string
+			)
+			BeforeAll(func() {
+				s := runMockArchive()
+
+				// Test producer.
+				go func() {
+					sourceCh <- s.URL + "/debian/"
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+
+				// Expected data.
+				expected = []string{
+					s.URL + mockSuitePath,
+					s.URL + "/debian/dists/" + mockSuite + "-updates/",
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stream the suites that are not excluded", func() {
+				Expect(actual).To(ConsistOf(expected))
+			})
+		})
+	})
+})
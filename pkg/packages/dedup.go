@@ -0,0 +1,148 @@
+package packages
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Deduplicator is a pipeline stage that sends the packages found more times,
+// such as through multiple mirrors of the same repository, only once.
+// By default, the packages found more times are merged in a single package
+// of which the mirrors are the alternative locations.
+type Deduplicator struct {
+	mergeMirrors bool
+	logger       *log.Logger
+}
+
+type DeduplicatorOption func(d *Deduplicator)
+
+func WithDeduplicatorLogger(logger *log.Logger) DeduplicatorOption {
+	return func(d *Deduplicator) {
+		d.logger = logger
+	}
+}
+
+// WithDeduplicatorMergeMirrors sets whether to merge the locations of the packages found more times
+// in the mirrors of a single package, which is the default. As all the locations of a package are
+// known only when all the packages have been received, the packages are then sent only when the
+// source is closed. Otherwise, each package is sent as soon as it is received the first time,
+// and the locations of its duplicates are discarded.
+func WithDeduplicatorMergeMirrors(merge bool) DeduplicatorOption {
+	return func(d *Deduplicator) {
+		d.mergeMirrors = merge
+	}
+}
+
+func NewDeduplicator(o ...DeduplicatorOption) *Deduplicator {
+	d := &Deduplicator{mergeMirrors: true, logger: log.New()}
+	for _, f := range o {
+		f(d)
+	}
+
+	return d
+}
+
+// packageKey identifies the same build of a package.
+type packageKey struct {
+	name         string
+	epoch        string
+	version      string
+	release      string
+	architecture string
+	checksum     PackageChecksum
+}
+
+func keyOf(p *Package) packageKey {
+	return packageKey{
+		name:         p.name,
+		epoch:        p.epoch,
+		version:      p.version,
+		release:      p.release,
+		architecture: p.architecture,
+		checksum:     p.checksum,
+	}
+}
+
+// Run runs a pipeline stage of which the output is a channel of packages.
+// The source of the stage is a channel of packages.
+func (d *Deduplicator) Run(ctx context.Context, sourceCh chan *Package) chan *Package {
+	destCh := make(chan *Package)
+
+	go func() {
+		defer close(destCh)
+
+		if d.mergeMirrors {
+			d.merge(ctx, sourceCh, destCh)
+			return
+		}
+
+		seen := make(map[packageKey]bool)
+		for pkg := range sourceCh {
+			d.logger.WithField("package", pkg.Locate()).Debug("receive")
+
+			k := keyOf(pkg)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			d.logger.WithField("package", pkg.Locate()).Debug("send")
+			if !Send(ctx, destCh, pkg) {
+				return
+			}
+		}
+	}()
+
+	return destCh
+}
+
+// merge sends the packages received from sourceCh when it is closed,
+// each with the locations of its duplicates as mirrors.
+func (d *Deduplicator) merge(ctx context.Context, sourceCh chan *Package, destCh chan *Package) {
+	var (
+		keys     []packageKey
+		packages = make(map[packageKey]*Package)
+	)
+
+	for pkg := range sourceCh {
+		d.logger.WithField("package", pkg.Locate()).Debug("receive")
+
+		k := keyOf(pkg)
+		v, ok := packages[k]
+		if !ok {
+			keys = append(keys, k)
+			packages[k] = pkg
+			continue
+		}
+		v.addMirrors(pkg.Locate())
+		v.addMirrors(pkg.Mirrors()...)
+	}
+
+	for _, k := range keys {
+		d.logger.WithField("package", packages[k].Locate()).Debug("send")
+		if !Send(ctx, destCh, packages[k]) {
+			return
+		}
+	}
+}
+
+// addMirrors adds the alternative locations of the package, skipping the known ones.
+func (p *Package) addMirrors(locations ...string) {
+	for _, v := range locations {
+		if v == p.location || contains(p.mirrors, v) {
+			continue
+		}
+		p.mirrors = append(p.mirrors, v)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+//go:build all_tests || all_unit_tests || (unit_tests && dedup)
+
+package packages_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+var _ = Describe("Deduplication", func() {
+	var (
+		ctx     = context.Background()
+		edge    = "https://mirrors.edge.kernel.org/centos/9-stream/AppStream/x86_64/os/"
+		archive = "https://archive.kernel.org/centos-vault/9-stream/AppStream/x86_64/os/"
+	)
+
+	newPackage := func(repo, rel, checksum string) *packages.Package {
+		return packages.NewPackage(
+			packages.WithName("kernel-devel"),
+			packages.WithArchitecture("x86_64"),
+			packages.WithEpoch("0"),
+			packages.WithVersion("5.14.0+"+rel),
+			packages.WithRelease(rel),
+			packages.WithChecksum("sha256", checksum),
+			packages.WithRepository(repo),
+			packages.WithLocation(repo+"Packages/kernel-devel-5.14.0-"+rel+".x86_64.rpm"),
+		)
+	}
+
+	run := func(options []packages.DeduplicatorOption, source ...*packages.Package) []*packages.Package {
+		sourceCh := make(chan *packages.Package)
+		go func() {
+			for _, v := range source {
+				sourceCh <- v
+			}
+			close(sourceCh)
+		}()
+
+		var actual []*packages.Package
+		for v := range packages.NewDeduplicator(options...).Run(ctx, sourceCh) {
+			actual = append(actual, v)
+		}
+
+		return actual
+	}
+
+	It("Should send the same package of more mirrors once", func() {
+		actual := run([]packages.DeduplicatorOption{packages.WithDeduplicatorMergeMirrors(false)},
+			newPackage(edge, "362.el9", "a"),
+			newPackage(archive, "362.el9", "a"),
+			newPackage(edge, "362.el9", "a"),
+		)
+
+		Expect(actual).To(HaveLen(1))
+		Expect(actual[0].Locate()).To(HavePrefix(edge))
+		Expect(actual[0].Mirrors()).To(BeEmpty())
+	})
+
+	It("Should send the packages as soon as they are received when not merging the mirrors", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sourceCh := make(chan *packages.Package)
+		destCh := packages.NewDeduplicator(packages.WithDeduplicatorMergeMirrors(false)).Run(ctx, sourceCh)
+
+		sourceCh <- newPackage(edge, "362.el9", "a")
+		Eventually(destCh).Should(Receive(WithTransform((*packages.Package).Locate, HavePrefix(edge))))
+
+		// The crawl is stopped before all the packages have been found.
+		cancel()
+		close(sourceCh)
+		Eventually(destCh).Should(BeClosed())
+	})
+
+	It("Should merge the same package of more mirrors by default", func() {
+		actual := run(nil,
+			newPackage(edge, "362.el9", "a"),
+			newPackage(archive, "362.el9", "a"),
+			newPackage(edge, "362.el9", "a"),
+		)
+
+		Expect(actual).To(HaveLen(1))
+		Expect(actual[0].Locate()).To(HavePrefix(edge))
+		Expect(actual[0].Mirrors()).To(ConsistOf(archive + "Packages/kernel-devel-5.14.0-362.el9.x86_64.rpm"))
+	})
+
+	It("Should not merge different builds", func() {
+		actual := run(nil,
+			newPackage(edge, "362.el9", "a"),
+			newPackage(archive, "284.el9", "b"),
+		)
+
+		Expect(actual).To(HaveLen(2))
+		for _, v := range actual {
+			Expect(v.Mirrors()).To(BeEmpty())
+		}
+	})
+
+	It("Should not merge packages with different checksums", func() {
+		actual := run(nil,
+			newPackage(edge, "362.el9", "a"),
+			newPackage(archive, "362.el9", "b"),
+		)
+
+		Expect(actual).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,10 @@
+package packages
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrMatchModeNotSupported = errors.New("the name match mode is not supported")
+	ErrFormatNotSupported    = errors.New("the output format is not supported")
+)
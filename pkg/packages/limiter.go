@@ -0,0 +1,190 @@
+package packages
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+)
+
+// Limiter limits the concurrent requests sent by the pipeline stages, overall and by host,
+// and the rate of the requests by host, so that the mirrors are not overloaded.
+// The zero limits are unlimited.
+type Limiter struct {
+	global chan struct{}
+
+	hostConcurrency int
+	hostRate        float64
+	hostBurst       int
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+type hostLimiter struct {
+	sem    chan struct{}
+	bucket *tokenBucket
+}
+
+type LimiterOption func(l *Limiter)
+
+// WithLimiterConcurrency sets the maximum number of concurrent requests to all the hosts.
+func WithLimiterConcurrency(n int) LimiterOption {
+	return func(l *Limiter) {
+		if n > 0 {
+			l.global = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithLimiterHostConcurrency sets the maximum number of concurrent requests to each host.
+func WithLimiterHostConcurrency(n int) LimiterOption {
+	return func(l *Limiter) {
+		l.hostConcurrency = n
+	}
+}
+
+// WithLimiterHostRate sets the maximum number of requests per second to each host,
+// of which up to burst can be sent at once.
+func WithLimiterHostRate(rate float64, burst int) LimiterOption {
+	return func(l *Limiter) {
+		l.hostRate = rate
+		l.hostBurst = burst
+	}
+}
+
+func NewLimiter(o ...LimiterOption) *Limiter {
+	l := &Limiter{hosts: make(map[string]*hostLimiter)}
+	for _, f := range o {
+		f(l)
+	}
+	if l.hostBurst < 1 {
+		l.hostBurst = 1
+	}
+
+	return l
+}
+
+// Acquire waits until a request can be sent to the host, or the context is done,
+// and returns the function that releases the request once completed.
+func (l *Limiter) Acquire(ctx context.Context, host string) (func(), error) {
+	h := l.host(host)
+
+	// The slot and the token of the host are acquired first, so that the requests
+	// waiting for a busy host do not hold the slots of the others.
+	if err := acquireSlot(ctx, h.sem); err != nil {
+		return nil, err
+	}
+	if h.bucket != nil {
+		if err := h.bucket.wait(ctx); err != nil {
+			releaseSlot(h.sem)
+			return nil, err
+		}
+	}
+	if err := acquireSlot(ctx, l.global); err != nil {
+		releaseSlot(h.sem)
+		return nil, err
+	}
+
+	return func() {
+		releaseSlot(l.global)
+		releaseSlot(h.sem)
+	}, nil
+}
+
+func (l *Limiter) host(name string) *hostLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.hosts[name]
+	if !ok {
+		h = &hostLimiter{}
+		if l.hostConcurrency > 0 {
+			h.sem = make(chan struct{}, l.hostConcurrency)
+		}
+		if l.hostRate > 0 {
+			h.bucket = newTokenBucket(l.hostRate, l.hostBurst)
+		}
+		l.hosts[name] = h
+	}
+
+	return h
+}
+
+// acquireSlot acquires a slot of the semaphore, which is unlimited when nil.
+func acquireSlot(ctx context.Context, sem chan struct{}) error {
+	if sem == nil {
+		return nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func releaseSlot(sem chan struct{}) {
+	if sem != nil {
+		<-sem
+	}
+}
+
+// tokenBucket is a token bucket that is refilled at a rate of tokens per second,
+// up to its burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token, waiting until one is available or the context is done.
+// The token is reserved before waiting, so that the waiting requests are sent in order.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// The token is given back, as the request is not sent.
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// WithLimiter returns a copy of the context with which the requests sent
+// by the stages of the pipelines are limited by the limiter.
+func WithLimiter(ctx context.Context, l *Limiter) context.Context {
+	return network.WithLimiter(ctx, l)
+}
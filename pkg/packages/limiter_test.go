@@ -0,0 +1,185 @@
+//go:build all_tests || all_unit_tests || (unit_tests && limiter)
+
+package packages_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	wfind "github.com/maxgio92/wfind/pkg/find"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+var _ = Describe("Limiter", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	// maxInFlight acquires n requests to each of the hosts concurrently, holding each
+	// for a while, and returns the maximum number of requests in flight at once.
+	maxInFlight := func(l *packages.Limiter, n int, hosts ...string) int32 {
+		var (
+			inFlight, max int32
+			wg            sync.WaitGroup
+		)
+		for _, host := range hosts {
+			for i := 0; i < n; i++ {
+				host := host
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					release, err := l.Acquire(ctx, host)
+					Expect(err).ToNot(HaveOccurred())
+					defer release()
+
+					current := atomic.AddInt32(&inFlight, 1)
+					for {
+						m := atomic.LoadInt32(&max)
+						if current <= m || atomic.CompareAndSwapInt32(&max, m, current) {
+							break
+						}
+					}
+					time.Sleep(10 * time.Millisecond)
+					atomic.AddInt32(&inFlight, -1)
+				}()
+			}
+		}
+		wg.Wait()
+
+		return max
+	}
+
+	Context("With a concurrency limit", func() {
+		It("Should limit the concurrent requests to all the hosts", func() {
+			l := packages.NewLimiter(packages.WithLimiterConcurrency(3))
+			Expect(maxInFlight(l, 5, "a.example.com", "b.example.com")).To(BeNumerically("<=", 3))
+		})
+	})
+
+	Context("With a host concurrency limit", func() {
+		It("Should limit the concurrent requests to each host", func() {
+			l := packages.NewLimiter(packages.WithLimiterHostConcurrency(2))
+			Expect(maxInFlight(l, 10, "a.example.com")).To(BeNumerically("<=", 2))
+		})
+		It("Should not limit the requests to the other hosts", func() {
+			l := packages.NewLimiter(packages.WithLimiterHostConcurrency(1))
+			Expect(maxInFlight(l, 3, "a.example.com", "b.example.com")).To(BeNumerically("<=", 2))
+		})
+	})
+
+	Context("With a host rate limit", func() {
+		It("Should space the requests to each host", func() {
+			l := packages.NewLimiter(packages.WithLimiterHostRate(50, 1))
+
+			start := time.Now()
+			for i := 0; i < 6; i++ {
+				release, err := l.Acquire(ctx, "a.example.com")
+				Expect(err).ToNot(HaveOccurred())
+				release()
+			}
+
+			// The first request is sent at once, the next ones every 20ms.
+			Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+		})
+		It("Should stop waiting when the context is done", func() {
+			l := packages.NewLimiter(packages.WithLimiterHostRate(0.1, 1))
+			release, err := l.Acquire(ctx, "a.example.com")
+			Expect(err).ToNot(HaveOccurred())
+			release()
+
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+
+			_, err = l.Acquire(ctx, "a.example.com")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+
+	Context("With the requests of a context", func() {
+		It("Should limit them", func() {
+			var inFlight, max int32
+			m := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					v := atomic.LoadInt32(&max)
+					if current <= v || atomic.CompareAndSwapInt32(&max, v, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+			}))
+			defer m.Close()
+
+			ctx := packages.WithLimiter(ctx, packages.NewLimiter(packages.WithLimiterHostConcurrency(2)))
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := network.Get(ctx, m.URL)
+					Expect(err).ToNot(HaveOccurred())
+					resp.Body.Close()
+				}()
+			}
+			wg.Wait()
+
+			Expect(atomic.LoadInt32(&max)).To(BeNumerically("<=", 2))
+			Expect(atomic.LoadInt32(&max)).To(BeNumerically(">", 0))
+		})
+		It("Should limit the requests of the finders", func() {
+			var inFlight, max int32
+			m := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					v := atomic.LoadInt32(&max)
+					if current <= v || atomic.CompareAndSwapInt32(&max, v, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+
+				for i := 0; i < 10; i++ {
+					fmt.Fprintf(w, `<a href="%d/">%d/</a>`, i, i)
+				}
+			}))
+			defer m.Close()
+
+			ctx := packages.WithLimiter(ctx, packages.NewLimiter(packages.WithLimiterHostConcurrency(2)))
+
+			// The finders are run without network.Find, so that only their requests are limited.
+			wg := sync.WaitGroup{}
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					found, err := wfind.NewFind(
+						wfind.WithSeedURLs([]string{m.URL + "/"}),
+						wfind.WithFilenameRegexp(`^\d+/?$`),
+						wfind.WithFileType(wfind.FileTypeDir),
+						wfind.WithRecursive(false),
+						wfind.WithClientTransport(network.FindTransport(ctx)),
+					).Find()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found.URLs).To(HaveLen(10))
+				}()
+			}
+			wg.Wait()
+
+			Expect(atomic.LoadInt32(&max)).To(BeNumerically("<=", 2))
+			Expect(atomic.LoadInt32(&max)).To(BeNumerically(">", 0))
+		})
+	})
+})
@@ -0,0 +1,168 @@
+package packages
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// MatchMode is the way the searched names are matched against the names of the packages.
+type MatchMode string
+
+const (
+	// MatchExact matches the names that are equal to the searched names.
+	MatchExact MatchMode = "exact"
+
+	// MatchGlob matches the names against glob patterns, with the syntax of path.Match.
+	MatchGlob MatchMode = "glob"
+
+	// MatchRegex matches the names against RE2 regular expressions, that must match the whole name.
+	MatchRegex MatchMode = "regex"
+)
+
+var (
+	MatchModes = []MatchMode{MatchExact, MatchGlob, MatchRegex}
+)
+
+// NameMatcher matches the names of the packages against the searched names,
+// that are either exact names, glob patterns or regular expressions.
+type NameMatcher struct {
+	mode        MatchMode
+	patterns    []string
+	names       map[string]bool
+	expressions []*regexp.Regexp
+}
+
+// NewNameMatcher returns a matcher of the patterns, in the specified match mode.
+// An empty mode is the exact match mode.
+func NewNameMatcher(mode MatchMode, patterns ...string) (*NameMatcher, error) {
+	if mode == "" {
+		mode = MatchExact
+	}
+
+	m := &NameMatcher{mode: mode, patterns: patterns, names: make(map[string]bool)}
+
+	for _, v := range patterns {
+		var expr string
+
+		switch mode {
+		case MatchExact:
+			m.names[v] = true
+			expr = regexp.QuoteMeta(v)
+		case MatchGlob:
+			if _, err := path.Match(v, ""); err != nil {
+				return nil, errors.Wrap(err, v)
+			}
+			expr = globToRegexp(v)
+		case MatchRegex:
+			expr = v
+		default:
+			return nil, errors.Wrap(ErrMatchModeNotSupported, string(mode))
+		}
+
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, errors.Wrap(err, v)
+		}
+		m.expressions = append(m.expressions, re)
+	}
+
+	return m, nil
+}
+
+// Mode returns the match mode.
+func (m *NameMatcher) Mode() MatchMode {
+	return m.mode
+}
+
+// Patterns returns the searched names as they have been specified.
+func (m *NameMatcher) Patterns() []string {
+	return m.patterns
+}
+
+// Expressions returns the anchored RE2 regular expressions equivalent to the patterns,
+// that backends can use to filter the packages before matching them.
+func (m *NameMatcher) Expressions() []string {
+	expressions := make([]string, 0, len(m.expressions))
+	for _, v := range m.expressions {
+		expressions = append(expressions, v.String())
+	}
+
+	return expressions
+}
+
+// Match returns the first pattern that matches the name, if any.
+func (m *NameMatcher) Match(name string) (string, bool) {
+	// Exact names are looked up without evaluating the expressions.
+	if m.mode == MatchExact {
+		if m.names[name] {
+			return name, true
+		}
+		return "", false
+	}
+
+	for k, v := range m.expressions {
+		if v.MatchString(name) {
+			return m.patterns[k], true
+		}
+	}
+
+	return "", false
+}
+
+// globToRegexp returns the RE2 regular expression equivalent to a glob pattern,
+// that must have been validated with path.Match.
+func globToRegexp(pattern string) string {
+	b := new(strings.Builder)
+	p := []rune(pattern)
+
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '*':
+			b.WriteString(`[^/]*`)
+		case '?':
+			b.WriteString(`[^/]`)
+		case '\\':
+			i++
+			b.WriteString(quoteRune(p[i]))
+		case '[':
+			b.WriteRune('[')
+			i++
+			if p[i] == '^' {
+				b.WriteRune('^')
+				i++
+			}
+			for ; p[i] != ']'; i++ {
+				switch p[i] {
+				case '\\':
+					i++
+					b.WriteString(quoteRune(p[i]))
+				case '-':
+					// Range operator.
+					b.WriteRune('-')
+				default:
+					b.WriteString(quoteRune(p[i]))
+				}
+			}
+			b.WriteRune(']')
+		default:
+			b.WriteString(quoteRune(p[i]))
+		}
+	}
+
+	return b.String()
+}
+
+// quoteRune returns r escaped, when it is an ASCII punctuation character,
+// so that it is a literal both in and out of a character class.
+func quoteRune(r rune) string {
+	if r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r)) {
+		return `\` + string(r)
+	}
+
+	return string(r)
+}
@@ -0,0 +1,47 @@
+//go:build all_tests || all_unit_tests || (unit_tests && matcher)
+
+package packages_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+var _ = Describe("Name matcher", func() {
+	DescribeTable("Match",
+		func(mode packages.MatchMode, patterns []string, name string, expected string, matched bool) {
+			m, err := packages.NewNameMatcher(mode, patterns...)
+			Expect(err).ToNot(HaveOccurred())
+
+			actual, ok := m.Match(name)
+			Expect(ok).To(Equal(matched))
+			Expect(actual).To(Equal(expected))
+		},
+		Entry("exact", packages.MatchExact, []string{"kernel-devel"}, "kernel-devel", "kernel-devel", true),
+		Entry("exact by default", packages.MatchMode(""), []string{"kernel-devel"}, "kernel-devel", "kernel-devel", true),
+		Entry("exact not matching prefixes", packages.MatchExact, []string{"kernel"}, "kernel-devel", "", false),
+		Entry("exact with metacharacters", packages.MatchExact, []string{"libstdc++"}, "libstdc++", "libstdc++", true),
+		Entry("glob", packages.MatchGlob, []string{"kernel-*-devel"}, "kernel-rt-devel", "kernel-*-devel", true),
+		Entry("glob not matching", packages.MatchGlob, []string{"kernel-*-devel"}, "kernel-devel", "", false),
+		Entry("glob with single character", packages.MatchGlob, []string{"python?"}, "python3", "python?", true),
+		Entry("glob with class", packages.MatchGlob, []string{"kernel-[a-z]*-devel"}, "kernel-64k-devel", "", false),
+		Entry("glob with negated class", packages.MatchGlob, []string{"kernel-[^a-z]*-devel"}, "kernel-64k-devel", "kernel-[^a-z]*-devel", true),
+		Entry("glob with escapes", packages.MatchGlob, []string{`libstdc\+\+*`}, "libstdc++-devel", `libstdc\+\+*`, true),
+		Entry("glob with metacharacters", packages.MatchGlob, []string{"libstdc++*"}, "libstdc++-devel", "libstdc++*", true),
+		Entry("regex", packages.MatchRegex, []string{"kernel-(rt|64k)-devel"}, "kernel-64k-devel", "kernel-(rt|64k)-devel", true),
+		Entry("regex matching the whole name", packages.MatchRegex, []string{"kernel"}, "kernel-devel", "", false),
+		Entry("first matching pattern", packages.MatchGlob, []string{"vim-*", "kernel-*", "kernel-devel"}, "kernel-devel", "kernel-*", true),
+	)
+
+	DescribeTable("Malformed",
+		func(mode packages.MatchMode, pattern string) {
+			_, err := packages.NewNameMatcher(mode, pattern)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("glob", packages.MatchGlob, "kernel-[-devel"),
+		Entry("regex", packages.MatchRegex, "kernel-(-devel"),
+		Entry("mode", packages.MatchMode("fuzzy"), "kernel"),
+	)
+})
@@ -0,0 +1,17 @@
+package pacman
+
+const (
+	FileDesc        = "desc"
+	FieldName       = "NAME"
+	FieldBase       = "BASE"
+	FieldVersion    = "VERSION"
+	FieldFilename   = "FILENAME"
+	FieldCSize      = "CSIZE"
+	FieldISize      = "ISIZE"
+	FieldSHA256Sum  = "SHA256SUM"
+	FieldLicense    = "LICENSE"
+	FieldArch       = "ARCH"
+	FieldBuildDate  = "BUILDDATE"
+	fieldDelimiter  = "%"
+	valuesDelimiter = "\n"
+)
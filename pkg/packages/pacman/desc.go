@@ -0,0 +1,39 @@
+package pacman
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Desc is a package description entry of a pacman repository database.
+// Each field is in the form "%NAME%" followed by one value per line,
+// and ends with an empty line. Multiple values are joined by new lines.
+type Desc map[string]string
+
+func parseDesc(r io.Reader) (Desc, error) {
+	desc := Desc{}
+	field := ""
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case line == "":
+			field = ""
+		case field == "" && strings.HasPrefix(line, fieldDelimiter) && strings.HasSuffix(line, fieldDelimiter):
+			field = strings.Trim(line, fieldDelimiter)
+		case field != "":
+			if v, ok := desc[field]; ok {
+				desc[field] = v + valuesDelimiter + line
+			} else {
+				desc[field] = line
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return desc, nil
+}
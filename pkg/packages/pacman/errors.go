@@ -0,0 +1,9 @@
+package pacman
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrSearchPackageNameMissing = errors.New("at least one package name must be specified")
+)
@@ -0,0 +1,127 @@
+//go:build all_tests || all_unit_tests || all_integration_tests || unit_tests || integration_tests
+
+package pacman_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo/v2"
+)
+
+const (
+	mockCoreDBPath  = "/archlinux/core/os/x86_64/core.db"
+	mockExtraDBPath = "/archlinux/extra/os/x86_64/extra.db"
+)
+
+type mockPackage struct {
+	name    string
+	version string
+	arch    string
+}
+
+var (
+	mockCorePackages = []mockPackage{
+		{name: "linux", version: "6.5.7.arch1-1", arch: "x86_64"},
+		{name: "linux-headers", version: "6.5.7.arch1-1", arch: "x86_64"},
+		{name: "linux-lts", version: "6.1.58-1", arch: "x86_64"},
+	}
+	mockExtraPackages = []mockPackage{
+		{name: "linux-zen-headers", version: "6.5.7.zen1-1", arch: "x86_64"},
+		{name: "vim", version: "9.0.2019-1", arch: "x86_64"},
+	}
+	mockDescF = `%%FILENAME%%
+%s-%s-%s.pkg.tar.zst
+
+%%NAME%%
+%s
+
+%%BASE%%
+linux
+
+%%VERSION%%
+%s
+
+%%DESC%%
+The Linux kernel and modules
+
+%%CSIZE%%
+137540520
+
+%%ISIZE%%
+144301953
+
+%%SHA256SUM%%
+2a3c5d09a3d4a1f1d3c1c6e3a0f0b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2
+
+%%ARCH%%
+%s
+
+%%BUILDDATE%%
+1697119487
+
+%%LICENSE%%
+GPL2
+
+%%DEPENDS%%
+coreutils
+kmod
+`
+)
+
+// repoDB returns a repository database tar archive that describes the specified packages.
+func repoDB(pkgs ...mockPackage) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, p := range pkgs {
+		dir := fmt.Sprintf("%s-%s/", p.name, p.version)
+		desc := fmt.Sprintf(mockDescF, p.name, p.version, p.arch, p.name, p.version, p.arch)
+		_ = tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0o755})
+		_ = tw.WriteHeader(&tar.Header{Name: dir + "desc", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(desc))})
+		_, _ = tw.Write([]byte(desc))
+	}
+	_ = tw.Close()
+
+	return buf.Bytes()
+}
+
+func gzipped(b []byte) []byte {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	_, _ = w.Write(b)
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func zstdCompressed(b []byte) []byte {
+	buf := new(bytes.Buffer)
+	w, _ := zstd.NewWriter(buf)
+	_, _ = io.Copy(w, bytes.NewReader(b))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// runMockMirror starts a mock server that serves a gzip compressed core database
+// and a zstd compressed extra database.
+func runMockMirror() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(mockCoreDBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(gzipped(repoDB(mockCorePackages...)))
+	})
+	mux.HandleFunc(mockExtraDBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(zstdCompressed(repoDB(mockExtraPackages...)))
+	})
+
+	s := httptest.NewServer(mux)
+	DeferCleanup(s.Close)
+
+	return s
+}
@@ -0,0 +1,195 @@
+package pacman
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"net/url"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/internal/compression"
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// Package is a package as described by the desc entry of a repository database.
+type Package struct {
+	Name      string
+	Base      string
+	Version   string
+	Arch      string
+	Filename  string
+	CSize     string
+	ISize     string
+	SHA256Sum string
+	BuildDate string
+	License   string
+}
+
+func packageFromDesc(d Desc) *Package {
+	return &Package{
+		Name:      d[FieldName],
+		Base:      d[FieldBase],
+		Version:   d[FieldVersion],
+		Arch:      d[FieldArch],
+		Filename:  d[FieldFilename],
+		CSize:     d[FieldCSize],
+		ISize:     d[FieldISize],
+		SHA256Sum: d[FieldSHA256Sum],
+		BuildDate: d[FieldBuildDate],
+		License:   d[FieldLicense],
+	}
+}
+
+type PackageSearch struct {
+	names     []string
+	matchMode packages.MatchMode
+	logger    *log.Logger
+}
+
+type PackageSearchOption func(s *PackageSearch)
+
+func WithPackageNames(names ...string) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.names = names
+	}
+}
+
+// WithPackageMatchMode sets how the names are matched against the names of the packages.
+// The names can be exact names (default), glob patterns or RE2 regular expressions.
+func WithPackageMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.matchMode = mode
+	}
+}
+
+func WithPackageLogger(logger *log.Logger) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.logger = logger
+	}
+}
+
+func NewPackageSearcher(o ...PackageSearchOption) *PackageSearch {
+	ps := &PackageSearch{logger: log.New()}
+	for _, f := range o {
+		f(ps)
+	}
+
+	return ps
+}
+
+func (ps *PackageSearch) validate() error {
+	if len(ps.names) == 0 {
+		return ErrSearchPackageNameMissing
+	}
+
+	_, err := packages.NewNameMatcher(ps.matchMode, ps.names...)
+
+	return err
+}
+
+// Run runs a pipeline stage of which the output is a channel of the packages
+// that match the searched names.
+// The source of the stage is a channel of repository database (<repo>.db) URL strings.
+func (ps *PackageSearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
+	destCh := make(chan *packages.Package)
+	if err := ps.validate(); err != nil {
+		ps.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "pacman.PackageSearch", sourceCh, err)
+		return destCh
+	}
+
+	matcher, _ := packages.NewNameMatcher(ps.matchMode, ps.names...)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			ps.logger.WithField("database", source).Debug("receive")
+			// The packages are in the same directory of the database.
+			u, err := url.Parse(source)
+			if err != nil {
+				packages.ReportError(ctx, "pacman.PackageSearch", source, err)
+				return
+			}
+			u.Path = path.Dir(u.Path)
+
+			pkgs, err := ps.packagesFromDB(ctx, source, matcher)
+			if err != nil {
+				ps.logger.WithError(err).WithField("database", source).Debug("error reading database")
+				packages.ReportError(ctx, "pacman.PackageSearch", source, err)
+				return
+			}
+
+			for _, pkg := range pkgs {
+				pkgURL, err := url.JoinPath(u.String(), pkg.Filename)
+				if err != nil {
+					continue
+				}
+				query, _ := matcher.Match(pkg.Name)
+				ps.logger.WithField("package", pkgURL).Debug("send")
+				p := packages.NewPackage(
+					packages.WithName(pkg.Name),
+					packages.WithQuery(query),
+					packages.WithVersion(pkg.Version),
+					packages.WithLocation(pkgURL),
+					packages.WithArchitecture(pkg.Arch),
+				)
+				if !packages.Send(ctx, destCh, p) {
+					return
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+// packagesFromDB streams the repository database archive and returns the packages
+// with the searched names. The archive compression is detected from its content,
+// as the database file name has no compression extension.
+func (ps *PackageSearch) packagesFromDB(ctx context.Context, dbURL string, matcher *packages.NameMatcher) ([]*Package, error) {
+	resp, err := network.Get(ctx, dbURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r, err := compression.NewReader(resp.Body, dbURL)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var pkgs []*Package
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Each package has a directory named <name>-<version>-<release>
+		// that contains the desc entry.
+		if !h.FileInfo().Mode().IsRegular() || path.Base(h.Name) != FileDesc {
+			continue
+		}
+
+		desc, err := parseDesc(tr)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := matcher.Match(desc[FieldName]); ok {
+			pkgs = append(pkgs, packageFromDesc(desc))
+		}
+	}
+
+	return pkgs, nil
+}
@@ -0,0 +1,136 @@
+//go:build all_tests || all_unit_tests || (unit_tests && packages && pacman)
+
+package pacman_test
+
+import (
+	"context"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/pacman"
+)
+
+var _ = Describe("Packages search", func() {
+	var (
+		search *pacman.PackageSearch
+		ctx    = context.Background()
+	)
+
+	Context("With names", func() {
+		BeforeEach(func() {
+			search = pacman.NewPackageSearcher(
+				pacman.WithPackageNames("linux-headers", "linux-zen-headers"),
+			)
+		})
+		Context("with gzip and zstd compressed databases", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan *packages.Package)
+				actual   []*packages.Package
+				mirror   string
+			)
+			BeforeAll(func() {
+				s := runMockMirror()
+				mirror = s.URL
+
+				// Test producer.
+				go func() {
+					sourceCh <- s.URL + mockCoreDBPath
+					sourceCh <- s.URL + mockExtraDBPath
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stage results from all the databases", func() {
+				Expect(actual).To(HaveLen(2))
+			})
+			It("Should stage the package metadata", func() {
+				for _, v := range actual {
+					Expect(v.Describe()).To(BeElementOf("linux-headers", "linux-zen-headers"))
+					Expect(v.Version()).ToNot(BeEmpty())
+					Expect(v.Architecture()).To(Equal("x86_64"))
+				}
+			})
+			It("Should stage the package location next to the database", func() {
+				for _, v := range actual {
+					Expect(v.Locate()).To(HavePrefix(mirror))
+					Expect(path.Base(v.Locate())).To(Equal(v.Describe() + "-" + v.Version() + "-x86_64.pkg.tar.zst"))
+				}
+			})
+		})
+		Context("with seed URLs closed channel", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan *packages.Package)
+				res      []*packages.Package
+			)
+			BeforeAll(func() {
+				// Noop test producer.
+				go func() {
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					res = append(res, v)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should not stage results", func() {
+				Expect(res).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("With name patterns", Ordered, func() {
+		var (
+			sourceCh = make(chan string)
+			actual   []*packages.Package
+		)
+		BeforeAll(func() {
+			search = pacman.NewPackageSearcher(
+				pacman.WithPackageNames("linux-*headers"),
+				pacman.WithPackageMatchMode(packages.MatchGlob),
+			)
+			s := runMockMirror()
+
+			// Test producer.
+			go func() {
+				sourceCh <- s.URL + mockCoreDBPath
+				sourceCh <- s.URL + mockExtraDBPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range search.Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+		})
+		It("Should stage the packages matching the patterns", func() {
+			Expect(actual).To(HaveLen(2))
+		})
+		It("Should stage the pattern each result matched", func() {
+			for _, v := range actual {
+				Expect(v.Query()).To(Equal("linux-*headers"))
+			}
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -49,7 +50,7 @@ func WithLogger(logger *log.Logger) GenericProducerOption {
 }
 
 func NewGenericProducer(opts ...GenericProducerOption) *GenericProducer {
-	producer := new(GenericProducer)
+	producer := &GenericProducer{logger: log.New()}
 	for _, f := range opts {
 		f(producer)
 	}
@@ -58,7 +59,8 @@ func NewGenericProducer(opts ...GenericProducerOption) *GenericProducer {
 }
 
 // Produce is a mirror producer that streams mirror URLs.
-func (p *GenericProducer) Produce(_ context.Context) chan string {
+// It stops streaming when the context is done.
+func (p *GenericProducer) Produce(ctx context.Context) chan string {
 	data := make(chan string)
 
 	wg := new(sync.WaitGroup)
@@ -69,7 +71,7 @@ func (p *GenericProducer) Produce(_ context.Context) chan string {
 		go func() {
 			defer wg.Done()
 			p.logger.WithField("seed", v).Debug("send")
-			data <- v
+			Send(ctx, data, v)
 		}()
 	}
 	go func() {
@@ -84,15 +86,132 @@ func (p *GenericProducer) Produce(_ context.Context) chan string {
 // Stages
 // *****************************************************************
 
+// StageRunner is a pipeline stage. The stages return their output immediately
+// and process the data as it is received from the source, so that the
+// downstream stages start before the upstream ones complete. They stop sending
+// when the context is done, and close their output when their source is closed.
 type StageRunner interface {
 	Run(ctx context.Context, source chan string) chan string
 }
 
+// StageFunc is an adapter to use a function as a pipeline stage.
+type StageFunc func(ctx context.Context, source chan string) chan string
+
+func (f StageFunc) Run(ctx context.Context, source chan string) chan string {
+	return f(ctx, source)
+}
+
+// Merge is a pipeline fan-in stage that streams the data of all the sources
+// in a single channel, which is closed when all the sources are closed.
+func Merge[T any](ctx context.Context, sources ...chan T) chan T {
+	destCh := make(chan T)
+
+	wg := sync.WaitGroup{}
+
+	for _, source := range sources {
+		source := source
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range source {
+				if !Send(ctx, destCh, v) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(destCh)
+	}()
+
+	return destCh
+}
+
+// DefaultStageConcurrency is the maximum number of the values of its source
+// that a stage processes at once, unless set by WithStageConcurrency.
+const DefaultStageConcurrency = 64
+
+type stageConcurrencyKey struct{}
+
+// WithStageConcurrency returns a copy of the context with which each stage of the pipelines
+// run with it processes up to n values of its source at once, instead of DefaultStageConcurrency.
+func WithStageConcurrency(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, stageConcurrencyKey{}, n)
+}
+
+// stageConcurrency returns the maximum number of the values that a stage processes at once,
+// which is the one of the context, if any and positive, or DefaultStageConcurrency.
+func stageConcurrency(ctx context.Context) int {
+	if n, ok := ctx.Value(stageConcurrencyKey{}).(int); ok && n > 0 {
+		return n
+	}
+
+	return DefaultStageConcurrency
+}
+
+// ForEach calls f for each value received from the source until it is closed,
+// up to the stage concurrency of the context calls at once, and waits for the calls to return.
+// When the context is done, the values still received are skipped.
+func ForEach[T any](ctx context.Context, source chan T, f func(v T)) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, stageConcurrency(ctx))
+	)
+
+	for v := range source {
+		v := v
+		if ctx.Err() != nil {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			f(v)
+		}()
+	}
+	wg.Wait()
+}
+
+// Send sends the value to the channel, unless the context is done first,
+// so that the stages do not block on the consumers that stopped receiving.
+// It returns whether the value has been sent.
+func Send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Drain receives from the channel in the background until it is closed,
+// so that the upstream stages of a stage that stops early do not block.
+func Drain[T any](ch chan T) {
+	go func() {
+		for range ch {
+		}
+	}()
+}
+
 // SearchStageRunner is a pipeline stage runner.
 type SearchStageRunner interface {
 	Run(ctx context.Context, dbURLs chan string) chan *Package
 }
 
+// SearchStageFunc is an adapter to use a function as a search pipeline stage.
+type SearchStageFunc func(ctx context.Context, dbURLs chan string) chan *Package
+
+func (f SearchStageFunc) Run(ctx context.Context, dbURLs chan string) chan *Package {
+	return f(ctx, dbURLs)
+}
+
 // PackageDescriptor describes a package alongside its metadata.
 type PackageDescriptor interface {
 	Describe() string
@@ -105,11 +224,35 @@ type PackageLocator interface {
 	Locate() string
 }
 
+// PackageMatcher describes which of the requested search terms a package matched.
+type PackageMatcher interface {
+	Query() string
+}
+
+// PackageChecksum is the digest of a package file.
+type PackageChecksum struct {
+	Type  string
+	Value string
+}
+
 type Package struct {
-	name         string
-	version      string
-	location     string
-	architecture string
+	name          string
+	epoch         string
+	version       string
+	release       string
+	location      string
+	architecture  string
+	query         string
+	checksum      PackageChecksum
+	size          int64
+	installedSize int64
+	buildTime     time.Time
+	license       string
+	vendor        string
+	sourcePackage string
+	summary       string
+	repository    string
+	mirrors       []string
 }
 
 type PackageOption func(o *Package)
@@ -138,6 +281,91 @@ func WithArchitecture(architecture string) PackageOption {
 	}
 }
 
+// WithQuery sets the requested search term that the package matched.
+func WithQuery(query string) PackageOption {
+	return func(o *Package) {
+		o.query = query
+	}
+}
+
+func WithEpoch(epoch string) PackageOption {
+	return func(o *Package) {
+		o.epoch = epoch
+	}
+}
+
+func WithRelease(release string) PackageOption {
+	return func(o *Package) {
+		o.release = release
+	}
+}
+
+// WithChecksum sets the digest of the package file and its algorithm, such as sha256.
+func WithChecksum(checksumType string, value string) PackageOption {
+	return func(o *Package) {
+		o.checksum = PackageChecksum{Type: checksumType, Value: value}
+	}
+}
+
+// WithSize sets the size in bytes of the package file.
+func WithSize(size int64) PackageOption {
+	return func(o *Package) {
+		o.size = size
+	}
+}
+
+// WithInstalledSize sets the size in bytes of the package once installed.
+func WithInstalledSize(size int64) PackageOption {
+	return func(o *Package) {
+		o.installedSize = size
+	}
+}
+
+func WithBuildTime(buildTime time.Time) PackageOption {
+	return func(o *Package) {
+		o.buildTime = buildTime
+	}
+}
+
+func WithLicense(license string) PackageOption {
+	return func(o *Package) {
+		o.license = license
+	}
+}
+
+func WithVendor(vendor string) PackageOption {
+	return func(o *Package) {
+		o.vendor = vendor
+	}
+}
+
+// WithSourcePackage sets the name of the source package the package has been built from.
+func WithSourcePackage(sourcePackage string) PackageOption {
+	return func(o *Package) {
+		o.sourcePackage = sourcePackage
+	}
+}
+
+func WithSummary(summary string) PackageOption {
+	return func(o *Package) {
+		o.summary = summary
+	}
+}
+
+// WithRepository sets the URL of the repository the package has been found in.
+func WithRepository(repository string) PackageOption {
+	return func(o *Package) {
+		o.repository = repository
+	}
+}
+
+// WithMirrors sets the alternative locations of the package, other than its location.
+func WithMirrors(mirrors ...string) PackageOption {
+	return func(o *Package) {
+		o.mirrors = mirrors
+	}
+}
+
 func NewPackage(options ...PackageOption) *Package {
 	pkg := new(Package)
 	for _, f := range options {
@@ -151,6 +379,20 @@ func (p *Package) Describe() string     { return p.name }
 func (p *Package) Version() string      { return p.version }
 func (p *Package) Locate() string       { return p.location }
 func (p *Package) Architecture() string { return p.architecture }
+func (p *Package) Query() string        { return p.query }
+
+func (p *Package) Epoch() string             { return p.epoch }
+func (p *Package) Release() string           { return p.release }
+func (p *Package) Checksum() PackageChecksum { return p.checksum }
+func (p *Package) Size() int64               { return p.size }
+func (p *Package) InstalledSize() int64      { return p.installedSize }
+func (p *Package) BuildTime() time.Time      { return p.buildTime }
+func (p *Package) License() string           { return p.license }
+func (p *Package) Vendor() string            { return p.vendor }
+func (p *Package) SourcePackage() string     { return p.sourcePackage }
+func (p *Package) Summary() string           { return p.summary }
+func (p *Package) Repository() string        { return p.repository }
+func (p *Package) Mirrors() []string         { return p.mirrors }
 
 type PackageConverter interface {
 	Convert(ctx context.Context, r io.Reader) (io.Reader, error)
@@ -164,6 +406,8 @@ type PackageDownloader interface {
 // Sinks
 // *****************************************************************
 
+// SinkRunner is the last stage of a pipeline, which consumes the packages
+// until the source is closed.
 type SinkRunner interface {
-	Run(ctx context.Context, source chan string) error
+	Run(ctx context.Context, source chan *Package) error
 }
@@ -9,6 +9,8 @@ import (
 	. "github.com/onsi/gomega"
 	"net/url"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var _ = Describe("Pipeline run", func() {
@@ -24,7 +26,7 @@ var _ = Describe("Pipeline run", func() {
 		BeforeAll(func() {
 			// Producer.
 			producer := packages.NewGenericProducer(
-				"https://mirrors.edge.kernel.org/centos/",
+				packages.WithSeeds("https://mirrors.edge.kernel.org/centos/"),
 			)
 
 			// Stages.
@@ -68,8 +70,132 @@ var _ = Describe("Pipeline run", func() {
 			Expect(len(actual)).To(Equal(8))
 		})
 	})
+
+	Context("With a source that is not closed yet", func() {
+		It("Should stream the results as the source data is received", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			seeds := make(chan string)
+			search := packages.SearchStageFunc(func(ctx context.Context, source chan string) chan *packages.Package {
+				destCh := make(chan *packages.Package)
+				go func() {
+					defer close(destCh)
+					for v := range source {
+						if !packages.Send(ctx, destCh, packages.NewPackage(packages.WithLocation(v))) {
+							return
+						}
+					}
+				}()
+
+				return destCh
+			})
+			destCh := packages.RunSearchPipeline(ctx, producerStub(seeds), search, newStageStub("8-stream"))
+
+			seeds <- "https://mirrors.edge.kernel.org/centos/"
+			var actual *packages.Package
+			Eventually(destCh).Should(Receive(&actual))
+			Expect(actual.Locate()).To(Equal("https://mirrors.edge.kernel.org/centos/8-stream"))
+
+			close(seeds)
+			Eventually(destCh).Should(BeClosed())
+		})
+	})
+
+	Context("With a cancelled context", func() {
+		It("Should stop and close the output", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			producer := packages.NewGenericProducer(
+				packages.WithSeeds("https://mirrors.edge.kernel.org/centos/", "https://archive.kernel.org/centos-vault/"),
+			)
+			search := newSearchStageStub("vim-common", "vim-common", "vim-common", "vim-common")
+			destCh := packages.RunSearchPipeline(ctx, producer, search, newStageStub("8-stream", "9-stream"))
+
+			Eventually(destCh).Should(Receive())
+			cancel()
+			Eventually(destCh).Should(BeClosed())
+		})
+	})
 })
 
+var _ = Describe("ForEach", func() {
+	It("Should bound the calls running at once", func() {
+		var inFlight, max, calls int32
+		source := make(chan int)
+		go func() {
+			defer close(source)
+			for i := 0; i < 4*packages.DefaultStageConcurrency; i++ {
+				source <- i
+			}
+		}()
+
+		packages.ForEach(context.Background(), source, func(_ int) {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				v := atomic.LoadInt32(&max)
+				if current <= v || atomic.CompareAndSwapInt32(&max, v, current) {
+					break
+				}
+			}
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(time.Millisecond)
+		})
+
+		Expect(calls).To(BeNumerically("==", 4*packages.DefaultStageConcurrency))
+		Expect(max).To(BeNumerically("<=", packages.DefaultStageConcurrency))
+	})
+	It("Should bound the calls running at once by the stage concurrency of the context", func() {
+		var inFlight, max, calls int32
+		source := make(chan int)
+		go func() {
+			defer close(source)
+			for i := 0; i < 16; i++ {
+				source <- i
+			}
+		}()
+
+		ctx := packages.WithStageConcurrency(context.Background(), 2)
+		packages.ForEach(ctx, source, func(_ int) {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				v := atomic.LoadInt32(&max)
+				if current <= v || atomic.CompareAndSwapInt32(&max, v, current) {
+					break
+				}
+			}
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(time.Millisecond)
+		})
+
+		Expect(calls).To(BeNumerically("==", 16))
+		Expect(max).To(BeNumerically("<=", 2))
+	})
+	It("Should skip the values received when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		source := make(chan int, 2)
+		source <- 1
+		source <- 2
+		close(source)
+
+		called := false
+		packages.ForEach(ctx, source, func(_ int) { called = true })
+		Expect(called).To(BeFalse())
+	})
+})
+
+// producerStub is a pipeline producer that streams the data of a channel.
+type producerStub chan string
+
+func (p producerStub) Produce(_ context.Context) chan string {
+	return p
+}
+
 // stageStub is a pipeline stage stubs that returns channel with static data.
 type stageStub struct {
 	data []string
@@ -79,25 +205,27 @@ func newStageStub(data ...string) *stageStub {
 	return &stageStub{data: data}
 }
 
-func (s *stageStub) Run(_ context.Context, sourceCh chan string) chan string {
+func (s *stageStub) Run(ctx context.Context, sourceCh chan string) chan string {
 	destCh := make(chan string)
 
-	wg := sync.WaitGroup{}
-
-	for source := range sourceCh {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for _, v := range s.data {
-				s, _ := url.JoinPath(source, v)
-				destCh <- s
-			}
-		}()
-	}
-
 	go func() {
+		defer close(destCh)
+
+		wg := sync.WaitGroup{}
+		for source := range sourceCh {
+			source := source
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, v := range s.data {
+					s, _ := url.JoinPath(source, v)
+					if !packages.Send(ctx, destCh, s) {
+						return
+					}
+				}
+			}()
+		}
 		wg.Wait()
-		close(destCh)
 	}()
 
 	return destCh
@@ -111,16 +239,17 @@ func newSearchStageStub(data ...string) *searchStageStub {
 	return &searchStageStub{data: data}
 }
 
-func (s *searchStageStub) Run(_ context.Context, _ chan string) chan *packages.Package {
+func (s *searchStageStub) Run(ctx context.Context, _ chan string) chan *packages.Package {
 	destCh := make(chan *packages.Package)
 
 	wg := sync.WaitGroup{}
 
 	for _, v := range s.data {
+		v := v
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			destCh <- packages.NewPackage(v)
+			packages.Send(ctx, destCh, packages.NewPackage(packages.WithName(v)))
 		}()
 	}
 
@@ -0,0 +1,183 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+)
+
+// StageError is the failure of a pipeline stage to process a source,
+// which the stage skips to continue with the next ones.
+type StageError struct {
+	// Stage is the name of the stage, such as "rpm.DBSearch".
+	Stage string
+
+	// URL is the URL of the source that failed.
+	URL string
+
+	// StatusCode is the status of the HTTP response, when the failure
+	// is an unexpected response, and zero otherwise.
+	StatusCode int
+
+	// Err is the cause of the failure.
+	Err error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Stage, e.URL, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// StageSummary summarizes the failures of a pipeline stage.
+type StageSummary struct {
+	Stage string
+
+	// Count is the number of failures.
+	Count int
+
+	// StatusCodes are the numbers of failures by status of the HTTP response,
+	// for the failures that are unexpected responses.
+	StatusCodes map[int]int
+}
+
+// ErrorCollector collects the failures reported by the pipeline stages,
+// so that the sources that could not be searched can be told apart from
+// the ones in which no package has been found.
+type ErrorCollector struct {
+	mu     sync.Mutex
+	errors []*StageError
+	logger *log.Logger
+}
+
+type ErrorCollectorOption func(c *ErrorCollector)
+
+func WithErrorCollectorLogger(logger *log.Logger) ErrorCollectorOption {
+	return func(c *ErrorCollector) {
+		c.logger = logger
+	}
+}
+
+func NewErrorCollector(o ...ErrorCollectorOption) *ErrorCollector {
+	c := &ErrorCollector{logger: log.New()}
+	for _, f := range o {
+		f(c)
+	}
+
+	return c
+}
+
+// Collect collects the failure of a stage.
+func (c *ErrorCollector) Collect(err *StageError) {
+	c.logger.
+		WithField("stage", err.Stage).
+		WithField("url", err.URL).
+		WithError(err.Err).
+		Warn("stage failure")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.errors = append(c.errors, err)
+}
+
+// Errors returns the failures collected so far, in the order they were reported.
+func (c *ErrorCollector) Errors() []*StageError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*StageError{}, c.errors...)
+}
+
+// Summary returns the summaries of the failures collected so far, by stage name.
+func (c *ErrorCollector) Summary() []*StageSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byStage := make(map[string]*StageSummary)
+	for _, v := range c.errors {
+		s, ok := byStage[v.Stage]
+		if !ok {
+			s = &StageSummary{Stage: v.Stage, StatusCodes: make(map[int]int)}
+			byStage[v.Stage] = s
+		}
+		s.Count++
+		if v.StatusCode != 0 {
+			s.StatusCodes[v.StatusCode]++
+		}
+	}
+
+	summary := make([]*StageSummary, 0, len(byStage))
+	for _, v := range byStage {
+		summary = append(summary, v)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Stage < summary[j].Stage
+	})
+
+	return summary
+}
+
+// DrainInvalid drains the source of a stage of which the options are not valid, as no value can be
+// processed, and reports the failure once, as the one to process the first value received.
+func DrainInvalid[T any](ctx context.Context, stage string, source chan T, err error) {
+	go func() {
+		first := true
+		for v := range source {
+			if first {
+				ReportError(ctx, stage, sourceURL(v), err)
+				first = false
+			}
+		}
+	}()
+}
+
+// sourceURL returns the URL of a value of the source of a stage,
+// which is either a URL or a package.
+func sourceURL(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case *Package:
+		return v.Locate()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type errorCollectorKey struct{}
+
+// WithErrorCollector returns a copy of the context to which the stages of
+// the pipelines run with it report their failures.
+func WithErrorCollector(ctx context.Context, c *ErrorCollector) context.Context {
+	return context.WithValue(ctx, errorCollectorKey{}, c)
+}
+
+// ReportError reports the failure of a stage to process the source at url,
+// to the collector of the context, if any. The failures caused by the context
+// being done are not reported, as they are not failures of the sources.
+func ReportError(ctx context.Context, stage string, url string, err error) {
+	c, ok := ctx.Value(errorCollectorKey{}).(*ErrorCollector)
+	if !ok || err == nil {
+		return
+	}
+	if ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+
+	stageErr := &StageError{Stage: stage, URL: url, Err: err}
+	var statusErr *network.StatusError
+	if errors.As(err, &statusErr) {
+		stageErr.StatusCode = statusErr.StatusCode
+	}
+
+	c.Collect(stageErr)
+}
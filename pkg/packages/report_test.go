@@ -0,0 +1,118 @@
+//go:build all_tests || all_unit_tests || (unit_tests && report)
+
+package packages_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+var _ = Describe("Error collector", func() {
+	var (
+		c   *packages.ErrorCollector
+		ctx context.Context
+		url = "https://mirrors.edge.kernel.org/centos/9-stream/AppStream/x86_64/os/repodata/repomd.xml"
+	)
+
+	BeforeEach(func() {
+		c = packages.NewErrorCollector()
+		ctx = packages.WithErrorCollector(context.Background(), c)
+	})
+
+	Context("With failures reported", func() {
+		BeforeEach(func() {
+			packages.ReportError(ctx, "rpm.PackageSearch", url,
+				&network.StatusError{URL: url, StatusCode: http.StatusServiceUnavailable})
+			packages.ReportError(ctx, "rpm.DBSearch", url,
+				errors.Wrap(&network.StatusError{URL: url, StatusCode: http.StatusNotFound}, "error getting the metadata"))
+			packages.ReportError(ctx, "rpm.DBSearch", url, errors.New("connection reset"))
+			packages.ReportError(ctx, "rpm.DBSearch", url,
+				&network.StatusError{URL: url, StatusCode: http.StatusNotFound})
+		})
+		It("Should collect them in order", func() {
+			actual := c.Errors()
+			Expect(actual).To(HaveLen(4))
+			Expect(actual[0].Stage).To(Equal("rpm.PackageSearch"))
+			Expect(actual[0].URL).To(Equal(url))
+			Expect(actual[2].Err).To(MatchError("connection reset"))
+		})
+		It("Should extract the status codes of the responses", func() {
+			actual := c.Errors()
+			Expect(actual[0].StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(actual[1].StatusCode).To(Equal(http.StatusNotFound))
+			Expect(actual[2].StatusCode).To(BeZero())
+		})
+		It("Should summarize them by stage", func() {
+			Expect(c.Summary()).To(Equal([]*packages.StageSummary{
+				{Stage: "rpm.DBSearch", Count: 3, StatusCodes: map[int]int{http.StatusNotFound: 2}},
+				{Stage: "rpm.PackageSearch", Count: 1, StatusCodes: map[int]int{http.StatusServiceUnavailable: 1}},
+			}))
+		})
+		It("Should unwrap the causes", func() {
+			var statusErr *network.StatusError
+			Expect(errors.As(c.Errors()[1], &statusErr)).To(BeTrue())
+		})
+	})
+
+	Context("With a cancelled context", func() {
+		It("Should not collect the failures caused by the cancellation", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			packages.ReportError(ctx, "rpm.PackageSearch", url, errors.Wrap(context.Canceled, "error getting the database"))
+
+			Expect(c.Errors()).To(BeEmpty())
+		})
+	})
+
+	Context("DrainInvalid", func() {
+		It("Should report the invalid options once, as the failure of the first source", func() {
+			source := make(chan string, 2)
+			source <- url
+			source <- "https://archive.kernel.org/centos-vault/8/BaseOS/x86_64/os/repodata/repomd.xml"
+			close(source)
+
+			packages.DrainInvalid(ctx, "rpm.PackageSearch", source, errors.New("invalid options"))
+
+			Eventually(c.Errors).Should(HaveLen(1))
+			Expect(c.Errors()[0].URL).To(Equal(url))
+			Consistently(c.Errors).Should(HaveLen(1))
+		})
+		It("Should report the packages by location", func() {
+			source := make(chan *packages.Package, 1)
+			source <- packages.NewPackage(packages.WithLocation(url))
+			close(source)
+
+			packages.DrainInvalid(ctx, "rpm.VersionFilter", source, errors.New("invalid options"))
+
+			Eventually(c.Errors).Should(HaveLen(1))
+			Expect(c.Errors()[0].Stage).To(Equal("rpm.VersionFilter"))
+			Expect(c.Errors()[0].URL).To(Equal(url))
+		})
+		It("Should not report without sources", func() {
+			source := make(chan string)
+			close(source)
+
+			packages.DrainInvalid(ctx, "rpm.PackageSearch", source, errors.New("invalid options"))
+
+			Consistently(c.Errors).Should(BeEmpty())
+		})
+	})
+
+	Context("Without a collector", func() {
+		It("Should not fail", func() {
+			Expect(func() {
+				packages.ReportError(context.Background(), "rpm.PackageSearch", url, errors.New("connection reset"))
+			}).NotTo(Panic())
+			Expect(c.Errors()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,288 @@
+package rpm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+const (
+	FlagLT = "LT"
+	FlagLE = "LE"
+	FlagEQ = "EQ"
+	FlagGE = "GE"
+	FlagGT = "GT"
+)
+
+var (
+	operatorFlags = map[string]string{
+		"<":  FlagLT,
+		"<=": FlagLE,
+		"=":  FlagEQ,
+		"==": FlagEQ,
+		">=": FlagGE,
+		">":  FlagGT,
+	}
+)
+
+// Capability is a provided or required capability, optionally restricted
+// to a range of versions, such as "kernel-devel-uname-r = 5.14.0-362.el9.x86_64".
+type Capability struct {
+	Name  string
+	Flags string
+	EVR   EVR
+}
+
+// ParseCapability parses a capability in the form name [operator [epoch:]version[-release]],
+// where the operator is one of <, <=, =, >=, >, or one of the flags of the entries
+// of the databases (LT, LE, EQ, GE, GT).
+func ParseCapability(s string) (*Capability, error) {
+	fields := strings.Fields(s)
+
+	switch len(fields) {
+	case 1:
+		return &Capability{Name: fields[0]}, nil
+	case 3:
+		flags, ok := parseFlags(fields[1])
+		if !ok {
+			return nil, errors.Wrap(ErrCapabilityMalformed, s)
+		}
+
+		return &Capability{Name: fields[0], Flags: flags, EVR: ParseEVR(fields[2])}, nil
+	default:
+		return nil, errors.Wrap(ErrCapabilityMalformed, s)
+	}
+}
+
+// Match returns whether the entry satisfies the capability, that is
+// whether they have the same name and their version ranges overlap.
+// Entries without version, as well as capabilities without version, match any version.
+func (c *Capability) Match(e Entry) bool {
+	if c.Name != e.Name {
+		return false
+	}
+	if c.Flags == "" || e.Flags == "" {
+		return true
+	}
+
+	return rangesOverlap(e.Flags, e.EVR(), c.Flags, c.EVR)
+}
+
+// rangesOverlap returns whether the range of versions a, with its flags, overlaps
+// with the range of versions b, with its flags, as rpm does with capabilities.
+func rangesOverlap(aFlags string, a EVR, bFlags string, b EVR) bool {
+	aLess, aEqual, aGreater, _ := flagsSense(aFlags)
+	bLess, bEqual, bGreater, _ := flagsSense(bFlags)
+
+	switch sense := a.Compare(b); {
+	case sense < 0:
+		return aGreater || bLess
+	case sense > 0:
+		return aLess || bGreater
+	default:
+		return (aEqual && bEqual) || (aLess && bLess) || (aGreater && bGreater)
+	}
+}
+
+// parseFlags returns the flags of an operator, such as >=, or of a flag, such as GE.
+func parseFlags(operator string) (string, bool) {
+	if flags, ok := operatorFlags[operator]; ok {
+		return flags, true
+	}
+
+	flags := strings.ToUpper(operator)
+	_, _, _, ok := flagsSense(flags)
+
+	return flags, ok
+}
+
+func flagsSense(flags string) (less bool, equal bool, greater bool, ok bool) {
+	switch flags {
+	case FlagLT:
+		return true, false, false, true
+	case FlagLE:
+		return true, true, false, true
+	case FlagEQ:
+		return false, true, false, true
+	case FlagGE:
+		return false, true, true, true
+	case FlagGT:
+		return false, false, true, true
+	default:
+		return false, false, false, false
+	}
+}
+
+// CapabilitySearch is a pipeline stage that searches the packages that provide
+// or require capabilities, in the XML primary databases.
+type CapabilitySearch struct {
+	provides []string
+	requires []string
+	logger   *log.Logger
+}
+
+type CapabilitySearchOption func(s *CapabilitySearch)
+
+// WithCapabilityProvides sets the capabilities provided by the packages to search for.
+func WithCapabilityProvides(capabilities ...string) CapabilitySearchOption {
+	return func(cs *CapabilitySearch) {
+		cs.provides = capabilities
+	}
+}
+
+// WithCapabilityRequires sets the capabilities required by the packages to search for.
+func WithCapabilityRequires(capabilities ...string) CapabilitySearchOption {
+	return func(cs *CapabilitySearch) {
+		cs.requires = capabilities
+	}
+}
+
+func WithCapabilityLogger(logger *log.Logger) CapabilitySearchOption {
+	return func(cs *CapabilitySearch) {
+		cs.logger = logger
+	}
+}
+
+func NewCapabilitySearcher(o ...CapabilitySearchOption) *CapabilitySearch {
+	cs := &CapabilitySearch{logger: log.New()}
+	for _, f := range o {
+		f(cs)
+	}
+
+	return cs
+}
+
+// validate returns the parsed capabilities to search for, by their original string.
+func (cs *CapabilitySearch) validate() (map[string]*Capability, map[string]*Capability, error) {
+	if len(cs.provides) == 0 && len(cs.requires) == 0 {
+		return nil, nil, ErrSearchCapabilityMissing
+	}
+
+	provides, err := parseCapabilities(cs.provides)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	requires, err := parseCapabilities(cs.requires)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return provides, requires, nil
+}
+
+// Run runs a pipeline stage of which the output is a channel of packages.
+// The source of the stage is a channel of XML primary database URL strings.
+func (cs *CapabilitySearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
+	destCh := make(chan *packages.Package)
+
+	provides, requires, err := cs.validate()
+	if err != nil {
+		cs.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "rpm.CapabilitySearch", sourceCh, err)
+		return destCh
+	}
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			cs.logger.WithField("database", source).Debug("receive")
+			pkgs, err := cs.packagesFromDB(ctx, source, provides, requires)
+			if err != nil {
+				cs.logger.WithField("database", source).WithError(err).Debug("search")
+				reportError(ctx, "rpm.CapabilitySearch", source, err)
+				return
+			}
+			for _, pkg := range pkgs {
+				cs.logger.WithField("package", pkg.Locate()).Debug("send")
+				if !packages.Send(ctx, destCh, pkg) {
+					return
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+func (cs *CapabilitySearch) packagesFromDB(ctx context.Context, dbURL string, provides, requires map[string]*Capability) ([]*packages.Package, error) {
+	if !isSupportedDB(dbURL) {
+		return nil, ErrDBFormatNotSupported
+	}
+
+	names := make([]string, 0, len(provides)+len(requires))
+	for _, v := range provides {
+		names = append(names, v.Name)
+	}
+	for _, v := range requires {
+		names = append(names, v.Name)
+	}
+
+	nodes, err := nodesFromXMLDB(ctx, dbURL, dataPackageXPath, dataPackageXPath+entriesFilter(names))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []*packages.Package
+	for pkg := range packagesFromXML(ctx, nodes) {
+		for query, c := range provides {
+			if matchAny(c, pkg.Format.Provides.Entries) {
+				if p, err := pkg.toPackage(query, dbURL); err == nil {
+					pkgs = append(pkgs, p)
+				}
+			}
+		}
+		for query, c := range requires {
+			if matchAny(c, pkg.Format.Requires.Entries) {
+				if p, err := pkg.toPackage(query, dbURL); err == nil {
+					pkgs = append(pkgs, p)
+				}
+			}
+		}
+	}
+
+	return pkgs, nil
+}
+
+func parseCapabilities(capabilities []string) (map[string]*Capability, error) {
+	parsed := make(map[string]*Capability, len(capabilities))
+	for _, v := range capabilities {
+		c, err := ParseCapability(v)
+		if err != nil {
+			return nil, err
+		}
+		parsed[v] = c
+	}
+
+	return parsed, nil
+}
+
+func matchAny(c *Capability, entries []Entry) bool {
+	for _, e := range entries {
+		if c.Match(e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// entriesFilter returns an XPath predicate that matches the packages with entries of
+// any of the format sections (provides, requires and so on) named as any of the specified names.
+// It narrows down the packages to be decoded, which are then matched against the capabilities.
+func entriesFilter(names []string) string {
+	predicates := make([]string, 0, len(names))
+	for _, v := range names {
+		predicates = append(predicates, "@name="+xpathLiteral(v))
+	}
+
+	// The alternatives are joined within a single entry predicate, as the union
+	// of more entry predicates is not evaluated correctly by the stream parser.
+	return "[format/*/rpm:entry[" + strings.Join(predicates, " or ") + "]]"
+}
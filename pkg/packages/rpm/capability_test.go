@@ -0,0 +1,117 @@
+//go:build all_tests || all_unit_tests || (unit_tests && capability && rpm)
+
+package rpm_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+var _ = Describe("Capability", func() {
+	DescribeTable("Match",
+		func(capability string, entry rpm.Entry, expected bool) {
+			c, err := rpm.ParseCapability(capability)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Match(entry)).To(Equal(expected))
+		},
+		Entry("unversioned capability",
+			"libbpf.so.1()(64bit)", rpm.Entry{Name: "libbpf.so.1()(64bit)"}, true),
+		Entry("unversioned entry",
+			"kernel-devel >= 5.14", rpm.Entry{Name: "kernel-devel"}, true),
+		Entry("different name",
+			"kernel-devel", rpm.Entry{Name: "kernel-core"}, false),
+		Entry("equal version",
+			"kernel-devel = 5.14.0-362.el9", rpm.Entry{Name: "kernel-devel", Flags: "EQ", Ver: "5.14.0", Rel: "362.el9"}, true),
+		Entry("different version",
+			"kernel-devel = 5.14.0-284.el9", rpm.Entry{Name: "kernel-devel", Flags: "EQ", Ver: "5.14.0", Rel: "362.el9"}, false),
+		Entry("version without release",
+			"kernel-devel = 5.14.0", rpm.Entry{Name: "kernel-devel", Flags: "EQ", Ver: "5.14.0", Rel: "362.el9"}, true),
+		Entry("lower bound",
+			"kernel-devel >= 5.14", rpm.Entry{Name: "kernel-devel", Flags: "EQ", Ver: "5.14.0", Rel: "362.el9"}, true),
+		Entry("upper bound",
+			"kernel-devel < 5.14", rpm.Entry{Name: "kernel-devel", Flags: "EQ", Ver: "5.14.0", Rel: "362.el9"}, false),
+		Entry("overlapping ranges",
+			"kernel-devel < 6", rpm.Entry{Name: "kernel-devel", Flags: "GE", Ver: "5.14.0"}, true),
+		Entry("disjoint ranges",
+			"kernel-devel LT 5", rpm.Entry{Name: "kernel-devel", Flags: "GE", Ver: "5.14.0"}, false),
+	)
+
+	DescribeTable("Malformed",
+		func(capability string) {
+			_, err := rpm.ParseCapability(capability)
+			Expect(err).To(MatchError(rpm.ErrCapabilityMalformed))
+		},
+		Entry("empty", ""),
+		Entry("missing version", "kernel-devel >="),
+		Entry("unknown operator", "kernel-devel ~ 5.14"),
+	)
+})
+
+var _ = Describe("Capability search mock", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	DescribeTable("With capabilities",
+		func(options []rpm.CapabilitySearchOption, expected []string) {
+			var actual []string
+
+			m := runMockRepository()
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- m.URL + mockPrimaryDBPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			var pkgs []*packages.Package
+			for v := range rpm.NewCapabilitySearcher(options...).Run(ctx, sourceCh) {
+				pkgs = append(pkgs, v)
+				actual = append(actual, v.Describe()+"-"+v.Version())
+			}
+
+			Expect(actual).To(ConsistOf(expected))
+		},
+		Entry("provided by name",
+			[]rpm.CapabilitySearchOption{rpm.WithCapabilityProvides("kernel-devel")},
+			[]string{"kernel-devel-5.14.0+362.el9", "kernel-devel-5.14.0+284.el9"},
+		),
+		Entry("provided with version",
+			[]rpm.CapabilitySearchOption{rpm.WithCapabilityProvides("kernel-devel-uname-r = 5.14.0-284.el9.x86_64")},
+			[]string{"kernel-devel-5.14.0+284.el9"},
+		),
+		Entry("provided with version constraint",
+			[]rpm.CapabilitySearchOption{rpm.WithCapabilityProvides("kernel-devel >= 5.14.0-300")},
+			[]string{"kernel-devel-5.14.0+362.el9"},
+		),
+		Entry("provided by more packages",
+			[]rpm.CapabilitySearchOption{rpm.WithCapabilityProvides("kernel-core", "vim-common > 8")},
+			[]string{"kernel-core-5.14.0+362.el9", "vim-common-8.2.2637+20.el9"},
+		),
+		Entry("required",
+			[]rpm.CapabilitySearchOption{rpm.WithCapabilityRequires("/bin/sh")},
+			[]string{
+				"kernel-core-5.14.0+362.el9",
+				"kernel-devel-5.14.0+362.el9",
+				"kernel-devel-5.14.0+284.el9",
+				"kernel-headers-5.14.0+362.el9",
+				"vim-common-8.2.2637+20.el9",
+			},
+		),
+		Entry("provided but not required",
+			[]rpm.CapabilitySearchOption{rpm.WithCapabilityRequires("kernel-devel")},
+			[]string{},
+		),
+		Entry("malformed",
+			[]rpm.CapabilitySearchOption{rpm.WithCapabilityProvides("kernel-devel ~ 5")},
+			[]string{},
+		),
+	)
+})
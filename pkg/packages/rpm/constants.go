@@ -1,6 +1,12 @@
 package rpm
 
 const (
-	DBTypePrimary = "primary"
-	DirRepodata   = "repodata"
+	DBTypePrimary   = "primary"
+	DBTypePrimaryDB = "primary_db"
+	DBTypeFilelists = "filelists"
+	DirRepodata     = "repodata"
+	DBFormatSQLite  = ".sqlite"
+
+	// DefaultDownloadConcurrency is the default number of packages downloaded in parallel.
+	DefaultDownloadConcurrency = 4
 )
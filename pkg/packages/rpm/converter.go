@@ -0,0 +1,252 @@
+package rpm
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/internal/compression"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// Converter converts the rpm files to tar archives of their payloads,
+// with no dependency on the rpm tools.
+type Converter struct {
+	logger *log.Logger
+}
+
+type ConverterOption func(c *Converter)
+
+func WithConverterLogger(logger *log.Logger) ConverterOption {
+	return func(c *Converter) {
+		c.logger = logger
+	}
+}
+
+var _ packages.PackageConverter = (*Converter)(nil)
+
+func NewConverter(o ...ConverterOption) *Converter {
+	c := &Converter{logger: log.New()}
+	for _, f := range o {
+		f(c)
+	}
+
+	return c
+}
+
+// Convert reads the rpm file from r and returns a tar stream of the files of its payload,
+// with their modes, owners, modification times, symbolic links and hard links.
+// The lead and the headers are read before returning, while the payload is
+// converted while the tar stream is read.
+func (c *Converter) Convert(ctx context.Context, r io.Reader) (io.Reader, error) {
+	payload, err := openPayload(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer payload.Close()
+		pw.CloseWithError(c.writeTar(ctx, newCpioReader(payload), pw))
+	}()
+
+	return pr, nil
+}
+
+// openPayload reads the lead and the headers of the rpm file,
+// and returns the decompressed cpio payload.
+func openPayload(r io.Reader) (io.ReadCloser, error) {
+	if err := readLead(r); err != nil {
+		return nil, err
+	}
+	if _, err := readHeader(r, true); err != nil {
+		return nil, err
+	}
+	h, err := readHeader(r, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if format, ok := h.string(tagPayloadFormat); ok && format != "cpio" {
+		return nil, errors.Wrap(ErrPayloadNotSupported, format)
+	}
+	// The decompressor is chosen by the magic bytes of the payload, so the tag
+	// is checked only for the compressors of which the payload has no magic.
+	if compressor, ok := h.string(tagPayloadCompressor); ok && compressor == "lzma" {
+		return nil, errors.Wrap(ErrPayloadNotSupported, compressor)
+	}
+
+	return compression.NewReader(r, "")
+}
+
+// linkKey identifies the entries of the same file, linked by hard links.
+type linkKey struct {
+	ino      int64
+	devMajor int64
+	devMinor int64
+}
+
+func (c *Converter) writeTar(ctx context.Context, cr *cpioReader, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	// The entries linked by hard links but the last have no data in the payload,
+	// so they are written as links to the last entry, which has the data.
+	pending := make(map[linkKey][]*cpioHeader)
+	var keys []linkKey
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		h, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name, err := entryName(h.Name)
+		if err != nil {
+			return err
+		}
+		// The root of the payload is not an entry of the tar stream.
+		if name == "" {
+			continue
+		}
+		h.Name = name
+
+		key := linkKey{ino: h.Ino, devMajor: h.DevMajor, devMinor: h.DevMinor}
+		regular := h.Mode&cpioModeType == cpioModeRegular
+		if regular && h.Nlink > 1 && h.Size == 0 {
+			if _, ok := pending[key]; !ok {
+				keys = append(keys, key)
+			}
+			pending[key] = append(pending[key], h)
+			continue
+		}
+
+		th, err := tarHeader(h, cr)
+		if err != nil {
+			return err
+		}
+		c.logger.WithField("file", th.Name).Trace("convert")
+		if err = tw.WriteHeader(th); err != nil {
+			return err
+		}
+		if th.Typeflag == tar.TypeReg {
+			if _, err = io.Copy(tw, cr); err != nil {
+				return errors.Wrap(err, "error reading the payload")
+			}
+		}
+
+		if regular && h.Nlink > 1 {
+			if err = writeLinks(tw, th.Name, pending[key]); err != nil {
+				return err
+			}
+			delete(pending, key)
+		}
+	}
+
+	// The files linked by hard links that are all empty have no entry with data,
+	// so the first one is written as an empty file.
+	for _, key := range keys {
+		links, ok := pending[key]
+		if !ok {
+			continue
+		}
+		th, err := tarHeader(links[0], cr)
+		if err != nil {
+			return err
+		}
+		if err = tw.WriteHeader(th); err != nil {
+			return err
+		}
+		if err = writeLinks(tw, th.Name, links[1:]); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeLinks(tw *tar.Writer, target string, links []*cpioHeader) error {
+	for _, v := range links {
+		th := &tar.Header{
+			Typeflag: tar.TypeLink,
+			Name:     v.Name,
+			Linkname: target,
+			Mode:     v.Mode & cpioModePerm,
+			Uid:      int(v.UID),
+			Gid:      int(v.GID),
+			ModTime:  time.Unix(v.Mtime, 0),
+		}
+		if err := tw.WriteHeader(th); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tarHeader returns the tar header of the cpio entry. The data of the
+// symbolic links, which is their target, is read from the cpio reader.
+func tarHeader(h *cpioHeader, cr *cpioReader) (*tar.Header, error) {
+	th := &tar.Header{
+		Name:     h.Name,
+		Mode:     h.Mode & cpioModePerm,
+		Uid:      int(h.UID),
+		Gid:      int(h.GID),
+		ModTime:  time.Unix(h.Mtime, 0),
+		Devmajor: h.RdevMajor,
+		Devminor: h.RdevMinor,
+	}
+
+	switch h.Mode & cpioModeType {
+	case cpioModeRegular:
+		th.Typeflag = tar.TypeReg
+		th.Size = h.Size
+	case cpioModeDir:
+		th.Typeflag = tar.TypeDir
+		th.Name += "/"
+	case cpioModeSymlink:
+		target, err := io.ReadAll(cr)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading the payload")
+		}
+		th.Typeflag = tar.TypeSymlink
+		th.Linkname = string(target)
+	case cpioModeChar:
+		th.Typeflag = tar.TypeChar
+	case cpioModeBlock:
+		th.Typeflag = tar.TypeBlock
+	case cpioModeFifo:
+		th.Typeflag = tar.TypeFifo
+	default:
+		return nil, errors.Wrapf(ErrPayloadNotSupported, "file type %o of %s", h.Mode&cpioModeType, h.Name)
+	}
+
+	return th, nil
+}
+
+// entryName returns the cleaned name of the payload entry relative to the root,
+// as the rpm payloads name the files as "./usr/...". The root is named "", and
+// the names that escape the root are not valid.
+func entryName(name string) (string, error) {
+	clean := path.Clean(strings.TrimLeft(name, "/"))
+	if clean == "." {
+		return "", nil
+	}
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.Wrapf(ErrRPMMalformed, "the payload entry %q is out of the root", name)
+	}
+
+	return clean, nil
+}
@@ -0,0 +1,214 @@
+//go:build all_tests || all_unit_tests || (unit_tests && converter && rpm)
+
+package rpm_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+// cpioEntry is an entry of the payload of a mock rpm file.
+type cpioEntry struct {
+	name  string
+	ino   int
+	mode  int
+	nlink int
+	data  string
+}
+
+// cpioArchive returns a cpio archive of the new ASCII format with the entries.
+func cpioArchive(entries ...cpioEntry) string {
+	b := new(bytes.Buffer)
+	pad := func() {
+		for b.Len()%4 != 0 {
+			b.WriteByte(0)
+		}
+	}
+	write := func(e cpioEntry) {
+		nlink := e.nlink
+		if nlink == 0 {
+			nlink = 1
+		}
+		fmt.Fprintf(b, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			e.ino, e.mode, 0, 0, nlink, 1700000000, len(e.data), 0, 0, 0, 0, len(e.name)+1, 0)
+		b.WriteString(e.name)
+		b.WriteByte(0)
+		pad()
+		b.WriteString(e.data)
+		pad()
+	}
+	for _, v := range entries {
+		write(v)
+	}
+	write(cpioEntry{name: "TRAILER!!!"})
+
+	return b.String()
+}
+
+// rpmHeader returns an rpm header structure with the string tags.
+func rpmHeader(tags map[int32]string) []byte {
+	index, data := new(bytes.Buffer), new(bytes.Buffer)
+	for tag, v := range tags {
+		_ = binary.Write(index, binary.BigEndian, []uint32{uint32(tag), 6, uint32(data.Len()), 1})
+		data.WriteString(v)
+		data.WriteByte(0)
+	}
+
+	b := bytes.NewBuffer([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+	_ = binary.Write(b, binary.BigEndian, []uint32{uint32(len(tags)), uint32(data.Len())})
+	b.Write(index.Bytes())
+	b.Write(data.Bytes())
+
+	return b.Bytes()
+}
+
+// rpmFile returns a mock rpm file with the payload compressed by compressor.
+func rpmFile(compressor string, payload []byte) []byte {
+	b := new(bytes.Buffer)
+
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb})
+	b.Write(lead)
+
+	// The signature header is padded to 8 bytes.
+	b.Write(rpmHeader(map[int32]string{1000: "sig"}))
+	for b.Len()%8 != 0 {
+		b.WriteByte(0)
+	}
+
+	b.Write(rpmHeader(map[int32]string{1124: "cpio", 1125: compressor}))
+	b.Write(payload)
+
+	return b.Bytes()
+}
+
+var _ = Describe("Converter", func() {
+	ctx := context.Background()
+
+	payload := cpioArchive(
+		cpioEntry{name: "./usr/src/kernels", ino: 1, mode: 0o40755, nlink: 2},
+		cpioEntry{name: "./usr/src/kernels/Makefile", ino: 2, mode: 0o100644, data: "all:\n"},
+		cpioEntry{name: "./usr/src/kernels/scripts/sign-file", ino: 3, mode: 0o100755, data: "#!/bin/sh\n"},
+		cpioEntry{name: "./usr/src/kernels/build", ino: 4, mode: 0o120777, data: "../kernels"},
+		cpioEntry{name: "./usr/src/kernels/a.h", ino: 5, mode: 0o100644, nlink: 2},
+		cpioEntry{name: "./usr/src/kernels/b.h", ino: 5, mode: 0o100644, nlink: 2, data: "#define A\n"},
+	)
+
+	type entry struct {
+		typeflag byte
+		mode     int64
+		linkname string
+		data     string
+	}
+
+	readTar := func(r io.Reader) map[string]entry {
+		entries := map[string]entry{}
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).To(BeNil())
+			data, err := io.ReadAll(tr)
+			Expect(err).To(BeNil())
+			entries[h.Name] = entry{typeflag: h.Typeflag, mode: h.Mode, linkname: h.Linkname, data: string(data)}
+		}
+
+		return entries
+	}
+
+	DescribeTable("Convert",
+		func(compressor string, compress func(string) []byte) {
+			r, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(rpmFile(compressor, compress(payload))))
+			Expect(err).To(BeNil())
+
+			Expect(readTar(r)).To(Equal(map[string]entry{
+				"usr/src/kernels/":                  {typeflag: tar.TypeDir, mode: 0o755},
+				"usr/src/kernels/Makefile":          {typeflag: tar.TypeReg, mode: 0o644, data: "all:\n"},
+				"usr/src/kernels/scripts/sign-file": {typeflag: tar.TypeReg, mode: 0o755, data: "#!/bin/sh\n"},
+				"usr/src/kernels/build":             {typeflag: tar.TypeSymlink, mode: 0o777, linkname: "../kernels"},
+				"usr/src/kernels/b.h":               {typeflag: tar.TypeReg, mode: 0o644, data: "#define A\n"},
+				"usr/src/kernels/a.h":               {typeflag: tar.TypeLink, mode: 0o644, linkname: "usr/src/kernels/b.h"},
+			}))
+		},
+		Entry("gzip", "gzip", gzipped),
+		Entry("xz", "xz", xzCompressed),
+		Entry("zstd", "zstd", zstdCompressed),
+	)
+
+	It("Should fail on a file that is not an rpm", func() {
+		_, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(make([]byte, 200)))
+		Expect(err).To(MatchError(rpm.ErrRPMMalformed))
+	})
+
+	It("Should fail on a payload compressor not supported", func() {
+		_, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(rpmFile("lzma", []byte(payload))))
+		Expect(err).To(MatchError(rpm.ErrPayloadNotSupported))
+	})
+
+	It("Should fail reading a truncated payload", func() {
+		file := rpmFile("gzip", gzipped(payload[:len(payload)/2]))
+		r, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(file))
+		Expect(err).To(BeNil())
+
+		_, err = io.Copy(io.Discard, r)
+		Expect(err).To(MatchError(rpm.ErrRPMMalformed))
+	})
+
+	It("Should clean the names of the entries", func() {
+		file := rpmFile("gzip", gzipped(cpioArchive(
+			cpioEntry{name: "/usr/src/kernels/./Makefile", ino: 1, mode: 0o100644, data: "all:\n"},
+			cpioEntry{name: "./usr/src/kernels/scripts/../Kbuild", ino: 2, mode: 0o100644},
+		)))
+		r, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(file))
+		Expect(err).To(BeNil())
+
+		Expect(readTar(r)).To(And(
+			HaveKey("usr/src/kernels/Makefile"),
+			HaveKey("usr/src/kernels/Kbuild"),
+		))
+	})
+
+	DescribeTable("Should fail on an entry out of the root",
+		func(name string) {
+			file := rpmFile("gzip", gzipped(cpioArchive(cpioEntry{name: name, ino: 1, mode: 0o100644})))
+			r, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(file))
+			Expect(err).To(BeNil())
+
+			_, err = io.Copy(io.Discard, r)
+			Expect(err).To(MatchError(rpm.ErrRPMMalformed))
+		},
+		Entry("parent", "../etc/passwd"),
+		Entry("parent of the root", "./usr/../../etc/passwd"),
+		Entry("absolute parent", "/../etc/passwd"),
+	)
+
+	It("Should fail on a cpio name too large", func() {
+		header := fmt.Sprintf("070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			1, 0o100644, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0x7fffffff, 0)
+		r, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(rpmFile("gzip", gzipped(header))))
+		Expect(err).To(BeNil())
+
+		_, err = io.Copy(io.Discard, r)
+		Expect(err).To(MatchError(rpm.ErrRPMMalformed))
+	})
+
+	It("Should fail on a header too large", func() {
+		file := rpmFile("gzip", gzipped(payload))
+		// The count of the index entries of the signature header, after the lead.
+		binary.BigEndian.PutUint32(file[96+8:], 0x7fffffff)
+
+		_, err := rpm.NewConverter().Convert(ctx, bytes.NewReader(file))
+		Expect(err).To(MatchError(rpm.ErrRPMMalformed))
+	})
+})
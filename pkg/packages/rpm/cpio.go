@@ -0,0 +1,171 @@
+package rpm
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	cpioHeaderSize = 110
+	cpioTrailer    = "TRAILER!!!"
+
+	// cpioMaxNameSize is the limit of the size of the entry names, with the terminating NUL.
+	cpioMaxNameSize = 4096
+
+	// The file type bits of the cpio modes.
+	cpioModeType    = 0o170000
+	cpioModeDir     = 0o040000
+	cpioModeRegular = 0o100000
+	cpioModeSymlink = 0o120000
+	cpioModeChar    = 0o020000
+	cpioModeBlock   = 0o060000
+	cpioModeFifo    = 0o010000
+	cpioModePerm    = 0o7777
+)
+
+var (
+	// The magics of the new ASCII cpio format, without and with the checksum.
+	cpioMagicNewc = []byte("070701")
+	cpioMagicCrc  = []byte("070702")
+)
+
+// cpioHeader is the header of an entry of a cpio archive of the new ASCII format.
+type cpioHeader struct {
+	Ino       int64
+	Mode      int64
+	UID       int64
+	GID       int64
+	Nlink     int64
+	Mtime     int64
+	Size      int64
+	DevMajor  int64
+	DevMinor  int64
+	RdevMajor int64
+	RdevMinor int64
+	Name      string
+}
+
+// cpioReader reads the entries of a cpio archive of the new ASCII format,
+// the format of the rpm payloads.
+type cpioReader struct {
+	r io.Reader
+
+	// offset is the offset in the archive, to which the padding is relative.
+	offset int64
+
+	// remaining is the size of the data of the current entry not read yet.
+	remaining int64
+}
+
+func newCpioReader(r io.Reader) *cpioReader {
+	return &cpioReader{r: r}
+}
+
+func (c *cpioReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+
+	n, err := c.r.Read(p)
+	c.offset += int64(n)
+	c.remaining -= int64(n)
+	if err == io.EOF && c.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+// Next skips the data of the current entry, and returns the header of the next one.
+// It returns io.EOF when the trailer is reached.
+func (c *cpioReader) Next() (*cpioHeader, error) {
+	if err := c.skip(c.remaining); err != nil {
+		return nil, err
+	}
+	c.remaining = 0
+	if err := c.align(); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, cpioHeaderSize)
+	if err := c.read(raw); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(raw, cpioMagicNewc) && !bytes.HasPrefix(raw, cpioMagicCrc) {
+		return nil, errors.Wrap(ErrPayloadNotSupported, "the cpio magic is not valid")
+	}
+
+	// The fields are hexadecimal numbers of 8 characters after the magic.
+	fields := make([]int64, 13)
+	for i := range fields {
+		start := len(cpioMagicNewc) + i*8
+		v, err := strconv.ParseUint(string(raw[start:start+8]), 16, 32)
+		if err != nil {
+			return nil, errors.Wrap(ErrRPMMalformed, "the cpio header is not valid")
+		}
+		fields[i] = int64(v)
+	}
+
+	if fields[11] < 1 || fields[11] > cpioMaxNameSize {
+		return nil, errors.Wrapf(ErrRPMMalformed, "the cpio name size %d is not valid", fields[11])
+	}
+	name := make([]byte, fields[11])
+	if err := c.read(name); err != nil {
+		return nil, err
+	}
+	if err := c.align(); err != nil {
+		return nil, err
+	}
+
+	h := &cpioHeader{
+		Ino:       fields[0],
+		Mode:      fields[1],
+		UID:       fields[2],
+		GID:       fields[3],
+		Nlink:     fields[4],
+		Mtime:     fields[5],
+		Size:      fields[6],
+		DevMajor:  fields[7],
+		DevMinor:  fields[8],
+		RdevMajor: fields[9],
+		RdevMinor: fields[10],
+		Name:      string(bytes.TrimRight(name, "\x00")),
+	}
+	if h.Name == cpioTrailer {
+		return nil, io.EOF
+	}
+	c.remaining = h.Size
+
+	return h, nil
+}
+
+func (c *cpioReader) read(p []byte) error {
+	n, err := io.ReadFull(c.r, p)
+	c.offset += int64(n)
+	if err != nil {
+		return errors.Wrap(ErrRPMMalformed, "the cpio archive is truncated")
+	}
+
+	return nil
+}
+
+func (c *cpioReader) skip(n int64) error {
+	skipped, err := io.CopyN(io.Discard, c.r, n)
+	c.offset += skipped
+	if err != nil {
+		return errors.Wrap(ErrRPMMalformed, "the cpio archive is truncated")
+	}
+
+	return nil
+}
+
+// align skips the padding to the next multiple of 4 bytes.
+func (c *cpioReader) align() error {
+	return c.skip((4 - c.offset%4) % 4)
+}
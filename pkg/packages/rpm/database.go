@@ -3,17 +3,12 @@ package rpm
 import (
 	"context"
 	"encoding/xml"
-	"fmt"
 	"github.com/antchfx/xmlquery"
 	"github.com/maxgio92/linux-packages/internal/network"
-	"github.com/pkg/errors"
+	"github.com/maxgio92/linux-packages/pkg/packages"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/sys/unix"
-	"net/http"
 	"net/url"
 	"path"
-	"sync"
-	"time"
 )
 
 type Data struct {
@@ -30,6 +25,7 @@ const (
 )
 
 type DBSearch struct {
+	types  []string
 	logger *log.Logger
 }
 
@@ -41,8 +37,21 @@ func WithDBLogger(logger *log.Logger) DBSearchOption {
 	}
 }
 
+// WithDBTypes sets the types of the databases to search for, in order of preference.
+// For each repository only the database of the first type that is available is sent,
+// so that the SQLite database can be preferred and the XML one used as fallback.
+// It defaults to the XML primary database.
+func WithDBTypes(types ...string) DBSearchOption {
+	return func(search *DBSearch) {
+		search.types = types
+	}
+}
+
 func NewDBSearcher(o ...DBSearchOption) *DBSearch {
-	dbs := new(DBSearch)
+	dbs := &DBSearch{
+		types:  []string{DBTypePrimary},
+		logger: log.New(),
+	}
 	for _, f := range o {
 		f(dbs)
 	}
@@ -55,86 +64,63 @@ func NewDBSearcher(o ...DBSearchOption) *DBSearch {
 func (ds *DBSearch) Run(ctx context.Context, sourceCh chan string) chan string {
 	destCh := make(chan string)
 
-	wg := sync.WaitGroup{}
-
-	for source := range sourceCh {
-		source := source
-		ds.logger.WithField("repo", source).Debug("receive")
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			u, err := url.Parse(source)
-			if err != nil {
-				return
-			}
-
-			reporoot := path.Dir(path.Dir(u.Path))
-			u.Path = reporoot
-
-			dbs := []Data{}
+	go func() {
+		defer close(destCh)
 
-			d, err := getPrimaryDBMetadatasFromRepoMetadataURL(ctx, source)
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			ds.logger.WithField("repo", source).Debug("receive")
+			dbs, err := getDBMetadatasFromRepoMetadataURL(ctx, source, ds.types)
 			if err != nil {
+				ds.logger.WithField("repo", source).WithError(err).Debug("search")
+				reportError(ctx, "rpm.DBSearch", source, err)
 				return
 			}
-			dbs = append(dbs, d...)
 
-			for k, _ := range dbs {
-				if u, err := url.JoinPath(u.String(), dbs[k].Location.Href); err == nil {
-					ds.logger.WithField("database", u).Debug("send")
-					destCh <- u
+			for k := range dbs {
+				u, err := dbURL(source, dbs[k].Location.Href)
+				if err != nil {
+					reportError(ctx, "rpm.DBSearch", source, err)
+					continue
+				}
+				ds.logger.WithField("database", u).Debug("send")
+				if !packages.Send(ctx, destCh, u) {
+					return
 				}
 			}
-		}()
-	}
-	go func() {
-		wg.Wait()
-		close(destCh)
+		})
 	}()
 
 	return destCh
 }
 
-func getPrimaryDBMetadatasFromRepoMetadataURL(ctx context.Context, metadataURL string) ([]Data, error) {
-	var dbs []Data
-
-	u, err := url.Parse(metadataURL)
+// getDBMetadatasFromRepoMetadataURL returns the metadata of the databases of the first
+// of the specified types that is listed in the repository metadata.
+func getDBMetadatasFromRepoMetadataURL(ctx context.Context, metadataURL string, types []string) ([]Data, error) {
+	dbsByType, err := getRepoMetadata(ctx, metadataURL)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{
-		Transport: network.DefaultClientTransport,
+	for _, t := range types {
+		if dbs, ok := dbsByType[t]; ok {
+			return dbs, nil
+		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return nil, err
-	}
+	return nil, nil
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		if !errors.Is(err, unix.ECONNRESET) {
-			return nil, err
-		}
+// getRepoMetadata returns the metadata of the databases listed in the repository metadata,
+// by database type.
+func getRepoMetadata(ctx context.Context, metadataURL string) (map[string][]Data, error) {
+	dbsByType := make(map[string][]Data)
 
-		// Dump retry logic.
-		// TODO: implement smart cyclic retry with increasing backoff.
-		time.Sleep(1 * time.Second)
-		resp, err = client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-	}
-	if resp.Body == nil {
-		return nil, ErrDBMetadataResponseEmpty
+	resp, err := network.Get(ctx, metadataURL)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK || resp.Body == nil {
-		return nil, fmt.Errorf("unexpected response: %d", resp.StatusCode)
-	}
-
 	doc, err := xmlquery.Parse(resp.Body)
 	if err != nil {
 		return nil, err
@@ -153,12 +139,22 @@ func getPrimaryDBMetadatasFromRepoMetadataURL(ctx context.Context, metadataURL s
 			return nil, err
 		}
 
-		if data.Type == DBTypePrimary {
-			dbs = append(dbs, *data)
-		}
+		dbsByType[data.Type] = append(dbsByType[data.Type], *data)
+	}
+
+	return dbsByType, nil
+}
+
+// dbURL returns the URL of the database at the location href,
+// relative to the root of the repository of the metadata at metadataURL.
+func dbURL(metadataURL string, href string) (string, error) {
+	u, err := url.Parse(metadataURL)
+	if err != nil {
+		return "", err
 	}
+	u.Path = path.Dir(path.Dir(u.Path))
 
-	return dbs, nil
+	return url.JoinPath(u.String(), href)
 }
 
 // TODO: get package metadata
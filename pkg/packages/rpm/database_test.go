@@ -5,12 +5,15 @@ package rpm_test
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"path"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
 	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
 )
 
@@ -137,3 +140,74 @@ var _ = Describe("Database search", func() {
 		})
 	})
 })
+
+var _ = Describe("Database search mock", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	DescribeTable("With database types",
+		func(options []rpm.DBSearchOption, expected string) {
+			var actual []string
+
+			m := runMockRepository()
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- m.URL + mockRepoMetadataPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range rpm.NewDBSearcher(options...).Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			Expect(actual).To(Equal([]string{m.URL + expected}))
+		},
+		Entry("default",
+			[]rpm.DBSearchOption{},
+			mockPrimaryDBPath,
+		),
+		Entry("preferring SQLite",
+			[]rpm.DBSearchOption{rpm.WithDBTypes(rpm.DBTypePrimaryDB, rpm.DBTypePrimary)},
+			mockPrimarySQLitePath,
+		),
+		Entry("preferring a missing type",
+			[]rpm.DBSearchOption{rpm.WithDBTypes("primary_zck", rpm.DBTypePrimary)},
+			mockPrimaryDBPath,
+		),
+	)
+
+	Context("With an unavailable repository", func() {
+		It("Should report the failure with the status code", func() {
+			m := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer m.Close()
+
+			c := packages.NewErrorCollector()
+			ctx := packages.WithErrorCollector(network.WithClient(ctx, network.NewClient(network.WithAttempts(1))), c)
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- m.URL + mockRepoMetadataPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			var actual []string
+			for v := range rpm.NewDBSearcher().Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			Expect(actual).To(BeEmpty())
+			Expect(c.Errors()).To(HaveLen(1))
+			Expect(c.Errors()[0].Stage).To(Equal("rpm.DBSearch"))
+			Expect(c.Errors()[0].URL).To(Equal(m.URL + mockRepoMetadataPath))
+			Expect(c.Errors()[0].StatusCode).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+})
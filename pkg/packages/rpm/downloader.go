@@ -0,0 +1,256 @@
+package rpm
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// Downloader downloads the files of the packages, verifying them
+// against the checksums of the repository metadata.
+// As a pipeline sink, it downloads the packages to a directory.
+type Downloader struct {
+	dir         string
+	concurrency int
+	logger      *log.Logger
+}
+
+type DownloaderOption func(d *Downloader)
+
+// WithDownloadDir sets the directory the package files are written to by Run
+// and DownloadFile, which is the working directory by default.
+func WithDownloadDir(dir string) DownloaderOption {
+	return func(d *Downloader) {
+		d.dir = dir
+	}
+}
+
+// WithDownloadConcurrency sets the maximum number of packages downloaded in parallel by Run.
+func WithDownloadConcurrency(concurrency int) DownloaderOption {
+	return func(d *Downloader) {
+		d.concurrency = concurrency
+	}
+}
+
+func WithDownloadLogger(logger *log.Logger) DownloaderOption {
+	return func(d *Downloader) {
+		d.logger = logger
+	}
+}
+
+var _ packages.PackageDownloader = (*Downloader)(nil)
+
+func NewDownloader(o ...DownloaderOption) *Downloader {
+	d := &Downloader{
+		dir:         ".",
+		concurrency: DefaultDownloadConcurrency,
+		logger:      log.New(),
+	}
+	for _, f := range o {
+		f(d)
+	}
+
+	return d
+}
+
+// Download returns the reader of the file at the location, which is an io.ReadCloser
+// to be closed. As the location alone has no checksum, the file is not verified:
+// DownloadPackage verifies the file against the checksum of the package.
+func (d *Downloader) Download(ctx context.Context, location string) (io.Reader, error) {
+	return d.get(ctx, location)
+}
+
+// DownloadPackage returns the reader of the file of the package, which is to be closed.
+// The reader fails with ErrChecksumMismatch at the end of the file, when the file does not
+// match the checksum of the package. The mirrors of the package are tried in order
+// when the request to the location fails.
+func (d *Downloader) DownloadPackage(ctx context.Context, p *packages.Package) (io.ReadCloser, error) {
+	h, err := newHash(p.Checksum().Type)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range append([]string{p.Locate()}, p.Mirrors()...) {
+		body, err := d.get(ctx, v)
+		if err != nil {
+			d.logger.WithField("location", v).WithError(err).Debug("download")
+			continue
+		}
+
+		return &checksumReader{body: body, hash: h, location: v, checksum: p.Checksum().Value}, nil
+	}
+
+	return nil, errors.Wrapf(ErrDownloadFailed, "no location of %s is available", p.Describe())
+}
+
+// DownloadFile downloads the package to the directory, and returns the path of the file.
+// The file is named as the one of the location, and is written only when verified.
+func (d *Downloader) DownloadFile(ctx context.Context, p *packages.Package) (string, error) {
+	u, err := url.Parse(p.Locate())
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(u.Path)
+
+	tmp, err := os.CreateTemp(d.dir, "."+name+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	r, err := d.DownloadPackage(ctx, p)
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+	defer r.Close()
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", errors.Wrap(err, "error reading the package file")
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(d.dir, name)
+	if err = os.Rename(tmp.Name(), dest); err != nil {
+		return "", err
+	}
+
+	return dest, nil
+}
+
+// get returns the body of the response to the request of the location.
+func (d *Downloader) get(ctx context.Context, location string) (io.ReadCloser, error) {
+	resp, err := network.Get(ctx, location)
+	if err != nil {
+		return nil, errors.Wrapf(ErrDownloadFailed, "%s: %v", location, err)
+	}
+
+	return resp.Body, nil
+}
+
+func (d *Downloader) validate() error {
+	if d.concurrency < 1 {
+		return errors.New("the download concurrency must be at least 1")
+	}
+
+	return nil
+}
+
+// Run downloads the packages received from the source to the directory,
+// until the source is closed. The packages are downloaded in parallel, up to
+// the concurrency limit, and the packages that fail are logged and skipped.
+// When the context is done, the downloads in progress are stopped, the rest
+// of the packages are skipped, and the context error is returned.
+func (d *Downloader) Run(ctx context.Context, source chan *packages.Package) error {
+	if err := d.validate(); err != nil {
+		d.logger.WithError(err).Error("validate")
+		packages.Drain(source)
+		return err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+		sem    = make(chan struct{}, d.concurrency)
+	)
+
+	for p := range source {
+		if ctx.Err() != nil {
+			continue
+		}
+		p := p
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			dest, err := d.DownloadFile(ctx, p)
+			if err != nil {
+				d.logger.WithField("package", p.Locate()).WithError(err).Error("download")
+				packages.ReportError(ctx, "rpm.Downloader", p.Locate(), err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				return
+			}
+			d.logger.WithField("package", p.Locate()).WithField("path", dest).Info("download")
+		}()
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Wrapf(ErrDownloadFailed, "%d packages", failed)
+	}
+
+	return nil
+}
+
+// checksumReader reads the file of a package, and fails with ErrChecksumMismatch
+// at the end of the file when the file does not match the checksum.
+type checksumReader struct {
+	body     io.ReadCloser
+	hash     hash.Hash
+	location string
+	checksum string
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if sum := hex.EncodeToString(r.hash.Sum(nil)); sum != r.checksum {
+			return n, errors.Wrapf(ErrChecksumMismatch, "%s: expected %s, got %s", r.location, r.checksum, sum)
+		}
+	}
+
+	return n, err
+}
+
+func (r *checksumReader) Close() error {
+	return r.body.Close()
+}
+
+// newHash returns the hash of the checksum type of the repository metadata.
+func newHash(checksumType string) (hash.Hash, error) {
+	switch checksumType {
+	// The "sha" type is the SHA-1 of the legacy repositories.
+	case "sha", "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha384":
+		return sha512.New384(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, errors.Wrapf(ErrChecksumNotSupported, "%q", checksumType)
+	}
+}
@@ -0,0 +1,182 @@
+//go:build all_tests || all_unit_tests || (unit_tests && downloader && rpm)
+
+package rpm_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+var _ = Describe("Downloader", func() {
+	var (
+		ctx     = context.Background()
+		content = []byte("kernel-devel package file")
+		server  *httptest.Server
+		stalled chan struct{}
+	)
+
+	sha256Sum := func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:])
+	}
+
+	newPackage := func(location, checksumType, checksum string, mirrors ...string) *packages.Package {
+		return packages.NewPackage(
+			packages.WithName("kernel-devel"),
+			packages.WithLocation(location),
+			packages.WithChecksum(checksumType, checksum),
+			packages.WithMirrors(mirrors...),
+		)
+	}
+
+	// download reads the file of the package to the end.
+	download := func(p *packages.Package) ([]byte, error) {
+		r, err := rpm.NewDownloader().DownloadPackage(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return io.ReadAll(r)
+	}
+
+	BeforeEach(func() {
+		stalled = make(chan struct{}, 1)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/Packages/kernel-devel.rpm", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(content)
+		})
+		mux.HandleFunc("/Packages/stalled.rpm", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			select {
+			case stalled <- struct{}{}:
+			default:
+			}
+			<-r.Context().Done()
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+	})
+
+	Context("Download", func() {
+		It("Should read the file at the location", func() {
+			var d packages.PackageDownloader = rpm.NewDownloader()
+			r, err := d.Download(ctx, server.URL+"/Packages/kernel-devel.rpm")
+			Expect(err).To(BeNil())
+			defer r.(io.Closer).Close()
+
+			Expect(io.ReadAll(r)).To(Equal(content))
+		})
+		It("Should fail when the location is not available", func() {
+			_, err := rpm.NewDownloader().Download(ctx, server.URL+"/missing/kernel-devel.rpm")
+			Expect(err).To(MatchError(rpm.ErrDownloadFailed))
+		})
+	})
+
+	Context("DownloadPackage", func() {
+		It("Should read the package file verified with sha256", func() {
+			p := newPackage(server.URL+"/Packages/kernel-devel.rpm", "sha256", sha256Sum(content))
+
+			Expect(download(p)).To(Equal(content))
+		})
+		It("Should read the package file verified with sha512", func() {
+			sum := sha512.Sum512(content)
+			p := newPackage(server.URL+"/Packages/kernel-devel.rpm", "sha512", hex.EncodeToString(sum[:]))
+
+			Expect(download(p)).To(Equal(content))
+		})
+		It("Should fail on a checksum mismatch", func() {
+			p := newPackage(server.URL+"/Packages/kernel-devel.rpm", "sha256", sha256Sum([]byte("other")))
+
+			_, err := download(p)
+			Expect(err).To(MatchError(rpm.ErrChecksumMismatch))
+		})
+		It("Should fail on a checksum type not supported", func() {
+			p := newPackage(server.URL+"/Packages/kernel-devel.rpm", "md5", "a")
+
+			_, err := download(p)
+			Expect(err).To(MatchError(rpm.ErrChecksumNotSupported))
+		})
+		It("Should fall back to the mirrors", func() {
+			p := newPackage(server.URL+"/missing/kernel-devel.rpm", "sha256", sha256Sum(content),
+				server.URL+"/Packages/kernel-devel.rpm")
+
+			Expect(download(p)).To(Equal(content))
+		})
+		It("Should fail when no location is available", func() {
+			p := newPackage(server.URL+"/missing/kernel-devel.rpm", "sha256", sha256Sum(content))
+
+			_, err := download(p)
+			Expect(err).To(MatchError(rpm.ErrDownloadFailed))
+		})
+	})
+
+	Context("Run", func() {
+		It("Should download the verified packages to the directory", func() {
+			dir := GinkgoT().TempDir()
+			source := make(chan *packages.Package)
+			go func() {
+				source <- newPackage(server.URL+"/Packages/kernel-devel.rpm", "sha256", sha256Sum(content))
+				source <- newPackage(server.URL+"/Packages/kernel-devel.rpm?corrupted", "sha256", sha256Sum(nil))
+				close(source)
+			}()
+
+			err := rpm.NewDownloader(
+				rpm.WithDownloadDir(dir),
+				rpm.WithDownloadConcurrency(1),
+			).Run(ctx, source)
+			Expect(err).To(MatchError(rpm.ErrDownloadFailed))
+
+			b, err := os.ReadFile(filepath.Join(dir, "kernel-devel.rpm"))
+			Expect(err).To(BeNil())
+			Expect(b).To(Equal(content))
+
+			// The files that fail the verification are removed.
+			entries, err := os.ReadDir(dir)
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(1))
+		})
+		It("Should stop when the context is done", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			// The packages are queued behind a download that does not complete.
+			source := make(chan *packages.Package)
+			go func() {
+				defer close(source)
+				for i := 0; i < 3; i++ {
+					p := newPackage(server.URL+"/Packages/stalled.rpm", "sha256", sha256Sum(content))
+					if !packages.Send(ctx, source, p) {
+						return
+					}
+				}
+			}()
+
+			errCh := make(chan error)
+			go func() {
+				errCh <- rpm.NewDownloader(
+					rpm.WithDownloadDir(GinkgoT().TempDir()),
+					rpm.WithDownloadConcurrency(1),
+				).Run(ctx, source)
+			}()
+
+			Eventually(stalled).Should(Receive())
+			cancel()
+			Eventually(errCh).Should(Receive(MatchError(context.Canceled)))
+		})
+	})
+})
@@ -1,11 +1,35 @@
 package rpm
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
 )
 
 var (
 	ErrDBFormatNotSupported     = errors.New("the database file format is not supported")
 	ErrDBMetadataResponseEmpty  = errors.New("response body is nil")
 	ErrSearchPackagaNameMissing = errors.New("at least one package name must be specified")
+	ErrSearchFilePathMissing    = errors.New("at least one file path must be specified")
+	ErrSearchCapabilityMissing  = errors.New("at least one capability must be specified")
+	ErrCapabilityMalformed      = errors.New("the capability is malformed")
+	ErrConstraintMalformed      = errors.New("the version constraint is malformed")
+	ErrDBMissing                = errors.New("the database is not listed in the repository metadata")
+	ErrChecksumNotSupported     = errors.New("the checksum type is not supported")
+	ErrChecksumMismatch         = errors.New("the checksum of the package file does not match")
+	ErrDownloadFailed           = errors.New("the download of packages failed")
+	ErrRPMMalformed             = errors.New("the rpm file is malformed")
+	ErrPayloadNotSupported      = errors.New("the rpm payload format is not supported")
 )
+
+// reportError reports the failure of the stage to process the source at url,
+// but the ones of the databases in formats that the stage does not search,
+// as the databases of all the formats are streamed to the stages of all the formats.
+func reportError(ctx context.Context, stage string, url string, err error) {
+	if errors.Is(err, ErrDBFormatNotSupported) {
+		return
+	}
+	packages.ReportError(ctx, stage, url, err)
+}
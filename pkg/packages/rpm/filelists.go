@@ -0,0 +1,204 @@
+package rpm
+
+import (
+	"context"
+	"encoding/xml"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// FilelistsPackage is a package entry of the filelists database.
+type FilelistsPackage struct {
+	XMLName xml.Name       `xml:"package"`
+	PkgID   string         `xml:"pkgid,attr"`
+	Name    string         `xml:"name,attr"`
+	Arch    string         `xml:"arch,attr"`
+	Version PackageVersion `xml:"version"`
+	Files   []string       `xml:"file"`
+}
+
+// FileSearch is a pipeline stage that searches the packages that ship files,
+// of which the paths match glob patterns, in the filelists databases.
+type FileSearch struct {
+	paths  []string
+	logger *log.Logger
+}
+
+type FileSearchOption func(s *FileSearch)
+
+// WithFilePaths sets the paths of the files to search for.
+// The paths can be glob patterns, with the syntax of path.Match.
+func WithFilePaths(paths ...string) FileSearchOption {
+	return func(fs *FileSearch) {
+		fs.paths = paths
+	}
+}
+
+func WithFileLogger(logger *log.Logger) FileSearchOption {
+	return func(fs *FileSearch) {
+		fs.logger = logger
+	}
+}
+
+func NewFileSearcher(o ...FileSearchOption) *FileSearch {
+	fs := &FileSearch{logger: log.New()}
+	for _, f := range o {
+		f(fs)
+	}
+
+	return fs
+}
+
+func (fs *FileSearch) validate() error {
+	if len(fs.paths) == 0 {
+		return ErrSearchFilePathMissing
+	}
+	for _, v := range fs.paths {
+		if _, err := path.Match(v, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run runs a pipeline stage of which the output is a channel of packages.
+// The source of the stage is a channel of repository metadata (repomd) URL strings.
+// The filelists database of each repository is searched for the file paths, and
+// the primary database for the locations of the packages that ship them.
+func (fs *FileSearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
+	destCh := make(chan *packages.Package)
+	if err := fs.validate(); err != nil {
+		fs.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "rpm.FileSearch", sourceCh, err)
+		return destCh
+	}
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			fs.logger.WithField("repo", source).Debug("receive")
+			pkgs, err := fs.packagesFromRepo(ctx, source)
+			if err != nil {
+				fs.logger.WithField("repo", source).WithError(err).Debug("search")
+				reportError(ctx, "rpm.FileSearch", source, err)
+				return
+			}
+			for _, pkg := range pkgs {
+				fs.logger.WithField("package", pkg.Locate()).Debug("send")
+				if !packages.Send(ctx, destCh, pkg) {
+					return
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+func (fs *FileSearch) packagesFromRepo(ctx context.Context, metadataURL string) ([]*packages.Package, error) {
+	dbs, err := getRepoMetadata(ctx, metadataURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbs[DBTypeFilelists]) == 0 || len(dbs[DBTypePrimary]) == 0 {
+		return nil, ErrDBMissing
+	}
+
+	filelistsURL, err := dbURL(metadataURL, dbs[DBTypeFilelists][0].Location.Href)
+	if err != nil {
+		return nil, err
+	}
+	primaryURL, err := dbURL(metadataURL, dbs[DBTypePrimary][0].Location.Href)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := fs.matchesFromFilelists(ctx, filelistsURL)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+
+	pkgIDs := make([]string, 0, len(matches))
+	for k := range matches {
+		pkgIDs = append(pkgIDs, k)
+	}
+
+	nodes, err := nodesFromXMLDB(ctx, primaryURL, dataPackageXPath, dataPackageXPath+checksumsFilter(pkgIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []*packages.Package
+	for pkg := range packagesFromXML(ctx, nodes) {
+		for _, pattern := range matches[pkg.Checksum.Value] {
+			if p, err := pkg.toPackage(pattern, primaryURL); err == nil {
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+
+	return pkgs, nil
+}
+
+// matchesFromFilelists returns the path patterns matched by the files of each package
+// of the filelists database at dbURL, by package ID.
+func (fs *FileSearch) matchesFromFilelists(ctx context.Context, dbURL string) (map[string][]string, error) {
+	nodes, err := nodesFromXMLDB(ctx, dbURL, dataPackageXPath, dataPackageXPath+pathsFilter(fs.paths))
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string][]string)
+	for _, n := range nodes {
+		pkg := &FilelistsPackage{}
+		if err = xml.Unmarshal([]byte(n.OutputXML(true)), pkg); err != nil {
+			continue
+		}
+		for _, pattern := range fs.paths {
+			for _, file := range pkg.Files {
+				if ok, _ := path.Match(pattern, file); ok {
+					matches[pkg.PkgID] = append(matches[pkg.PkgID], pattern)
+					break
+				}
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// pathsFilter returns an XPath predicate that matches the packages with files
+// of which the path starts with the static prefix of any of the patterns.
+// It narrows down the packages to be decoded, which are then matched against the patterns.
+func pathsFilter(patterns []string) string {
+	predicates := make([]string, 0, len(patterns))
+	for _, v := range patterns {
+		prefix := v
+		if i := strings.IndexAny(v, `*?[\`); i >= 0 {
+			prefix = v[:i]
+		}
+		predicates = append(predicates, "starts-with(., "+xpathLiteral(prefix)+")")
+	}
+
+	// The alternatives are joined within a single file predicate, as the union
+	// of more file predicates is not evaluated correctly by the stream parser.
+	return "[file[" + strings.Join(predicates, " or ") + "]]"
+}
+
+// checksumsFilter returns an XPath predicate that matches the packages
+// of which the checksum is equal to any of the specified package IDs.
+func checksumsFilter(pkgIDs []string) string {
+	predicates := make([]string, 0, len(pkgIDs))
+	for _, v := range pkgIDs {
+		predicates = append(predicates, "checksum="+xpathLiteral(v))
+	}
+
+	return "[" + strings.Join(predicates, " or ") + "]"
+}
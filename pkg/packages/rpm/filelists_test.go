@@ -0,0 +1,71 @@
+//go:build all_tests || all_unit_tests || (unit_tests && filelists && rpm)
+
+package rpm_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+var _ = Describe("File search mock", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	DescribeTable("With file paths",
+		func(paths []string, expected []string) {
+			var actual []string
+
+			m := runMockRepository()
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- m.URL + mockRepoMetadataPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			var pkgs []*packages.Package
+			for v := range rpm.NewFileSearcher(rpm.WithFilePaths(paths...)).Run(ctx, sourceCh) {
+				pkgs = append(pkgs, v)
+				actual = append(actual, v.Describe()+"-"+v.Version())
+			}
+
+			Expect(actual).To(ConsistOf(expected))
+			for _, v := range pkgs {
+				Expect(paths).To(ContainElement(v.Query()))
+				expectMockMetadata(v, m.URL)
+			}
+		},
+		Entry("exact path",
+			[]string{"/usr/include/linux/bpf.h"},
+			[]string{"kernel-headers-5.14.0+362.el9"},
+		),
+		Entry("glob pattern",
+			[]string{"/usr/src/kernels/*/Makefile"},
+			[]string{"kernel-devel-5.14.0+362.el9", "kernel-devel-5.14.0+284.el9"},
+		),
+		Entry("glob pattern matching more files of a package once",
+			[]string{"/usr/src/kernels/5.14.0-284.el9.x86_64/*"},
+			[]string{"kernel-devel-5.14.0+284.el9"},
+		),
+		Entry("multiple patterns",
+			[]string{"/lib/modules/*/vmlinuz", "/usr/share/vim/*/filetype.vim"},
+			[]string{"kernel-core-5.14.0+362.el9", "vim-common-8.2.2637+20.el9"},
+		),
+		Entry("missing path",
+			[]string{"/usr/bin/missing"},
+			[]string{},
+		),
+		Entry("malformed pattern",
+			[]string{"/usr/src/kernels/[/Makefile"},
+			[]string{},
+		),
+	)
+})
@@ -0,0 +1,139 @@
+package rpm
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// VersionFilter is a pipeline stage that filters the packages by version,
+// keeping the ones that satisfy all the constraints and, optionally, only
+// the newest build of each package name and architecture, per repository.
+type VersionFilter struct {
+	constraints []string
+	latest      bool
+	logger      *log.Logger
+}
+
+type VersionFilterOption func(f *VersionFilter)
+
+// WithVersionConstraints sets the constraints that the versions of the packages
+// must all satisfy, such as ">= 5.14" and "< 6".
+func WithVersionConstraints(constraints ...string) VersionFilterOption {
+	return func(vf *VersionFilter) {
+		vf.constraints = constraints
+	}
+}
+
+// WithVersionLatest sets whether to keep only the newest build of each package name
+// and architecture, per repository. As the newest build is known only when all the
+// packages have been received, the packages are sent when the source is closed.
+func WithVersionLatest(latest bool) VersionFilterOption {
+	return func(vf *VersionFilter) {
+		vf.latest = latest
+	}
+}
+
+func WithVersionLogger(logger *log.Logger) VersionFilterOption {
+	return func(vf *VersionFilter) {
+		vf.logger = logger
+	}
+}
+
+func NewVersionFilter(o ...VersionFilterOption) *VersionFilter {
+	vf := &VersionFilter{logger: log.New()}
+	for _, f := range o {
+		f(vf)
+	}
+
+	return vf
+}
+
+func (vf *VersionFilter) validate() ([]*Constraint, error) {
+	constraints := make([]*Constraint, 0, len(vf.constraints))
+	for _, v := range vf.constraints {
+		c, err := ParseConstraint(v)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+
+	return constraints, nil
+}
+
+// latestKey identifies the builds of which only the newest is kept.
+type latestKey struct {
+	name       string
+	arch       string
+	repository string
+}
+
+// Run runs a pipeline stage of which the output is a channel of packages.
+// The source of the stage is a channel of packages.
+func (vf *VersionFilter) Run(ctx context.Context, sourceCh chan *packages.Package) chan *packages.Package {
+	destCh := make(chan *packages.Package)
+
+	constraints, err := vf.validate()
+	if err != nil {
+		vf.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "rpm.VersionFilter", sourceCh, err)
+		return destCh
+	}
+
+	go func() {
+		defer close(destCh)
+
+		var (
+			keys   []latestKey
+			latest = make(map[latestKey]*packages.Package)
+		)
+
+		for pkg := range sourceCh {
+			vf.logger.WithField("package", pkg.Locate()).Debug("receive")
+
+			if !matchConstraints(PackageEVR(pkg), constraints) {
+				continue
+			}
+
+			if !vf.latest {
+				vf.logger.WithField("package", pkg.Locate()).Debug("send")
+				if !packages.Send(ctx, destCh, pkg) {
+					return
+				}
+				continue
+			}
+
+			k := latestKey{name: pkg.Describe(), arch: pkg.Architecture(), repository: pkg.Repository()}
+			v, ok := latest[k]
+			if !ok {
+				keys = append(keys, k)
+			}
+			if !ok || PackageEVR(pkg).Compare(PackageEVR(v)) > 0 {
+				latest[k] = pkg
+			}
+		}
+
+		for _, k := range keys {
+			vf.logger.WithField("package", latest[k].Locate()).Debug("send")
+			if !packages.Send(ctx, destCh, latest[k]) {
+				return
+			}
+		}
+	}()
+
+	return destCh
+}
+
+func matchConstraints(evr EVR, constraints []*Constraint) bool {
+	for _, c := range constraints {
+		if !c.Match(evr) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,114 @@
+package rpm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	leadSize = 96
+
+	// The sizes of the header structure and of the index entries.
+	headerIntroSize = 16
+	headerEntrySize = 16
+
+	// The limits of the header size, the same as the ones of rpm
+	// for the tags and the data of a header.
+	headerMaxEntries = 0xffff
+	headerMaxData    = 16 << 20
+
+	headerTypeString = 6
+
+	tagPayloadFormat     = 1124
+	tagPayloadCompressor = 1125
+)
+
+var (
+	leadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+)
+
+// headerEntry is an entry of the index of an rpm header, describing
+// where the value of a tag is in the data store.
+type headerEntry struct {
+	Tag    int32
+	Type   uint32
+	Offset int32
+	Count  uint32
+}
+
+// header is a header structure of an rpm file, such as the signature and the main ones.
+type header struct {
+	entries map[int32]headerEntry
+	data    []byte
+}
+
+// readLead reads the legacy lead of the rpm file, of which only the magic is verified.
+func readLead(r io.Reader) error {
+	lead := make([]byte, leadSize)
+	if _, err := io.ReadFull(r, lead); err != nil {
+		return errors.Wrap(ErrRPMMalformed, "error reading the lead")
+	}
+	if !bytes.HasPrefix(lead, leadMagic) {
+		return errors.Wrap(ErrRPMMalformed, "the lead magic is not valid")
+	}
+
+	return nil
+}
+
+// readHeader reads a header structure. When pad is true, the padding to 8 bytes
+// that follows the signature header is read too.
+func readHeader(r io.Reader, pad bool) (*header, error) {
+	intro := make([]byte, headerIntroSize)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, errors.Wrap(ErrRPMMalformed, "error reading the header")
+	}
+	if !bytes.HasPrefix(intro, headerMagic) {
+		return nil, errors.Wrap(ErrRPMMalformed, "the header magic is not valid")
+	}
+
+	count := binary.BigEndian.Uint32(intro[8:12])
+	size := binary.BigEndian.Uint32(intro[12:16])
+	if count > headerMaxEntries || size > headerMaxData {
+		return nil, errors.Wrap(ErrRPMMalformed, "the header is too large")
+	}
+
+	index := make([]headerEntry, count)
+	if err := binary.Read(r, binary.BigEndian, index); err != nil {
+		return nil, errors.Wrap(ErrRPMMalformed, "error reading the header index")
+	}
+
+	h := &header{entries: make(map[int32]headerEntry, count), data: make([]byte, size)}
+	if _, err := io.ReadFull(r, h.data); err != nil {
+		return nil, errors.Wrap(ErrRPMMalformed, "error reading the header data")
+	}
+	for _, v := range index {
+		h.entries[v.Tag] = v
+	}
+
+	if pad && size%8 != 0 {
+		if _, err := io.CopyN(io.Discard, r, int64(8-size%8)); err != nil {
+			return nil, errors.Wrap(ErrRPMMalformed, "error reading the header padding")
+		}
+	}
+
+	return h, nil
+}
+
+// string returns the value of a string tag, and whether the header has it.
+func (h *header) string(tag int32) (string, bool) {
+	e, ok := h.entries[tag]
+	if !ok || e.Type != headerTypeString || e.Offset < 0 || int(e.Offset) >= len(h.data) {
+		return "", false
+	}
+
+	data := h.data[e.Offset:]
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+
+	return string(data), true
+}
@@ -0,0 +1,319 @@
+//go:build all_tests || all_unit_tests || all_integration_tests || unit_tests || integration_tests
+
+package rpm_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ulikunitz/xz"
+	_ "modernc.org/sqlite"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+const (
+	mockRepoPath          = "/repo"
+	mockPrimaryDBPath     = mockRepoPath + "/repodata/primary.xml.gz"
+	mockPrimaryZstdDBPath = mockRepoPath + "/repodata/primary.xml.zst"
+	mockPrimaryXzDBPath   = mockRepoPath + "/repodata/primary.xml.xz"
+	mockPrimaryBz2DBPath  = mockRepoPath + "/repodata/primary.xml.bz2"
+	mockPrimarySQLitePath = mockRepoPath + "/repodata/primary.sqlite.xz"
+	mockRepoMetadataPath  = mockRepoPath + "/repodata/repomd.xml"
+	// mockXMLRepoMetadataPath is of a repository metadata document that lists the XML primary database only.
+	mockXMLRepoMetadataPath = mockRepoPath + "/repodata/repomd-xml.xml"
+	mockFilelistsDBPath     = mockRepoPath + "/repodata/filelists.xml.gz"
+	// mockPrimaryRawDBPath is of a gzip compressed database without the compression extension.
+	mockPrimaryRawDBPath = mockRepoPath + "/repodata/primary.xml"
+
+	// mockPrimaryBz2DBFile is a bzip2 compressed primaryXML(mockPackages...),
+	// as the standard library provides a bzip2 decompressor only.
+	mockPrimaryBz2DBFile = "testdata/primary.xml.bz2"
+)
+
+type mockPackage struct {
+	name  string
+	arch  string
+	ver   string
+	rel   string
+	files []string
+}
+
+// pkgID returns the mock package ID, which in real repositories is the checksum of the package.
+func (p mockPackage) pkgID() string {
+	return fmt.Sprintf("%s-%s-%s.%s", p.name, p.ver, p.rel, p.arch)
+}
+
+// sourceRPM returns the mock source package name, which in real repositories
+// is shared by the packages built from the same source.
+func (p mockPackage) sourceRPM() string {
+	return fmt.Sprintf("%s-%s-%s.src.rpm", p.name, p.ver, p.rel)
+}
+
+const (
+	mockSize          = 1024
+	mockInstalledSize = 4096
+	mockBuildTime     = 1700000000
+	mockLicense       = "GPLv2"
+	mockVendor        = "Mock"
+)
+
+var (
+	mockPackages = []mockPackage{
+		{name: "kernel-core", arch: "x86_64", ver: "5.14.0", rel: "362.el9", files: []string{
+			"/lib/modules/5.14.0-362.el9.x86_64/vmlinuz",
+		}},
+		{name: "kernel-devel", arch: "x86_64", ver: "5.14.0", rel: "362.el9", files: []string{
+			"/usr/src/kernels/5.14.0-362.el9.x86_64/Makefile",
+			"/usr/src/kernels/5.14.0-362.el9.x86_64/Kconfig",
+		}},
+		{name: "kernel-devel", arch: "x86_64", ver: "5.14.0", rel: "284.el9", files: []string{
+			"/usr/src/kernels/5.14.0-284.el9.x86_64/Makefile",
+			"/usr/src/kernels/5.14.0-284.el9.x86_64/Kconfig",
+		}},
+		{name: "kernel-headers", arch: "x86_64", ver: "5.14.0", rel: "362.el9", files: []string{
+			"/usr/include/linux/bpf.h",
+		}},
+		{name: "vim-common", arch: "x86_64", ver: "8.2.2637", rel: "20.el9", files: []string{
+			"/usr/share/vim/vim82/filetype.vim",
+		}},
+	}
+	mockPackageF = `
+<package type="rpm">
+  <name>%s</name>
+  <arch>%s</arch>
+  <version epoch="0" ver="%s" rel="%s"/>
+  <checksum type="sha256" pkgid="YES">%s</checksum>
+  <summary>%s</summary>
+  <description>%s</description>
+  <time file="%d" build="%d"/>
+  <size package="%d" installed="%d" archive="%d"/>
+  <location href="Packages/%s-%s-%s.%s.rpm"/>
+  <format>
+    <rpm:license>%s</rpm:license>
+    <rpm:vendor>%s</rpm:vendor>
+    <rpm:sourcerpm>%s</rpm:sourcerpm>
+    <rpm:provides>
+      <rpm:entry name="%s" flags="EQ" epoch="0" ver="%s" rel="%s"/>
+      <rpm:entry name="%s-uname-r" flags="EQ" epoch="0" ver="%s-%s.%s"/>
+    </rpm:provides>
+    <rpm:requires>
+      <rpm:entry name="/bin/sh"/>
+    </rpm:requires>
+  </format>
+</package>`
+)
+
+// primaryXML returns a primary database document that describes the specified packages.
+func primaryXML(pkgs ...mockPackage) string {
+	b := new(strings.Builder)
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(b,
+		`<metadata xmlns="http://linux.duke.edu/metadata/common" xmlns:rpm="http://linux.duke.edu/metadata/rpm" packages="%d">`,
+		len(pkgs),
+	)
+	for _, p := range pkgs {
+		fmt.Fprintf(b, mockPackageF,
+			p.name, p.arch, p.ver, p.rel,
+			p.pkgID(),
+			p.name, p.name,
+			mockBuildTime, mockBuildTime,
+			mockSize, mockInstalledSize, mockInstalledSize,
+			p.name, p.ver, p.rel, p.arch,
+			mockLicense, mockVendor, p.sourceRPM(),
+			p.name, p.ver, p.rel,
+			p.name, p.ver, p.rel, p.arch,
+		)
+	}
+	b.WriteString(`</metadata>`)
+
+	return b.String()
+}
+
+// filelistsXML returns a filelists database document that describes the files of the specified packages.
+func filelistsXML(pkgs ...mockPackage) string {
+	b := new(strings.Builder)
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(b, `<filelists xmlns="http://linux.duke.edu/metadata/filelists" packages="%d">`, len(pkgs))
+	for _, p := range pkgs {
+		fmt.Fprintf(b, `<package pkgid="%s" name="%s" arch="%s"><version epoch="0" ver="%s" rel="%s"/>`,
+			p.pkgID(), p.name, p.arch, p.ver, p.rel,
+		)
+		for _, f := range p.files {
+			fmt.Fprintf(b, `<file>%s</file>`, f)
+		}
+		b.WriteString(`</package>`)
+	}
+	b.WriteString(`</filelists>`)
+
+	return b.String()
+}
+
+func gzipped(s string) []byte {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func zstdCompressed(s string) []byte {
+	buf := new(bytes.Buffer)
+	w, _ := zstd.NewWriter(buf)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func xzCompressed(s string) []byte {
+	buf := new(bytes.Buffer)
+	w, _ := xz.NewWriter(buf)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// repoMetadataXML returns a repository metadata document that lists both the XML
+// and the SQLite primary databases, and the filelists database.
+func repoMetadataXML() string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo" xmlns:rpm="http://linux.duke.edu/metadata/rpm">
+  <data type="primary">
+    <location href="repodata/primary.xml.gz"/>
+  </data>
+  <data type="filelists">
+    <location href="repodata/filelists.xml.gz"/>
+  </data>
+  <data type="primary_db">
+    <location href="repodata/primary.sqlite.xz"/>
+  </data>
+</repomd>`
+}
+
+// xmlRepoMetadataXML returns a repository metadata document that lists the XML primary database only.
+func xmlRepoMetadataXML() string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo" xmlns:rpm="http://linux.duke.edu/metadata/rpm">
+  <data type="primary">
+    <location href="repodata/primary.xml.gz"/>
+  </data>
+</repomd>`
+}
+
+// primarySQLite returns a SQLite primary database of the specified packages,
+// with the subset of the createrepo schema that is queried.
+func primarySQLite(pkgs ...mockPackage) []byte {
+	f, err := os.CreateTemp(GinkgoT().TempDir(), "primary-*.sqlite")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(f.Close()).To(Succeed())
+
+	db, err := sql.Open("sqlite", f.Name())
+	Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+
+	_, err = db.Exec(`
+CREATE TABLE packages (pkgKey INTEGER PRIMARY KEY, pkgId TEXT, name TEXT, arch TEXT, version TEXT, epoch TEXT, release TEXT,
+  summary TEXT, time_build INTEGER, rpm_license TEXT, rpm_vendor TEXT, rpm_sourcerpm TEXT,
+  size_package INTEGER, size_installed INTEGER, location_href TEXT, location_base TEXT, checksum_type TEXT);
+CREATE TABLE provides (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER);
+CREATE INDEX packagename ON packages (name);
+CREATE INDEX providesname ON provides (name);`)
+	Expect(err).ToNot(HaveOccurred())
+
+	for k, p := range pkgs {
+		_, err = db.Exec(`INSERT INTO packages VALUES (?, ?, ?, ?, ?, '0', ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, 'sha256')`,
+			k, p.pkgID(), p.name, p.arch, p.ver, p.rel,
+			p.name, mockBuildTime, mockLicense, mockVendor, p.sourceRPM(),
+			mockSize, mockInstalledSize,
+			fmt.Sprintf("Packages/%s-%s-%s.%s.rpm", p.name, p.ver, p.rel, p.arch),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Packages provide their own name twice, with and without version, as in real repositories.
+		_, err = db.Exec(`INSERT INTO provides VALUES (?, 'EQ', '0', ?, ?, ?), (?, NULL, NULL, NULL, NULL, ?)`,
+			p.name, p.ver, p.rel, k, p.name, k,
+		)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(db.Close()).To(Succeed())
+
+	b, err := os.ReadFile(f.Name())
+	Expect(err).ToNot(HaveOccurred())
+
+	return b
+}
+
+// expectMockMetadata asserts that the package carries the metadata of the mock package
+// it describes, as served by the mock repository at serverURL.
+func expectMockMetadata(p *packages.Package, serverURL string) {
+	repo := serverURL + mockRepoPath + "/"
+
+	Expect(p.Repository()).To(Equal(repo))
+	Expect(p.Locate()).To(Equal(repo + "Packages/" + p.Checksum().Value + ".rpm"))
+	Expect(p.Epoch()).To(Equal("0"))
+	Expect(p.Version()).To(HaveSuffix("+" + p.Release()))
+	Expect(p.Checksum().Type).To(Equal("sha256"))
+	Expect(p.Checksum().Value).To(HavePrefix(p.Describe() + "-"))
+	Expect(p.Size()).To(Equal(int64(mockSize)))
+	Expect(p.InstalledSize()).To(Equal(int64(mockInstalledSize)))
+	Expect(p.BuildTime()).To(Equal(time.Unix(mockBuildTime, 0).UTC()))
+	Expect(p.License()).To(Equal(mockLicense))
+	Expect(p.Vendor()).To(Equal(mockVendor))
+	Expect(p.SourcePackage()).To(HaveSuffix(".src.rpm"))
+	Expect(p.Summary()).To(Equal(p.Describe()))
+}
+
+// runMockRepository starts a mock server that serves an rpm-md repository of mockPackages.
+// The mocha mock server is not used here as it does not preserve binary response bodies.
+func runMockRepository() *httptest.Server {
+	bz2DB, err := os.ReadFile(mockPrimaryBz2DBFile)
+	Expect(err).ToNot(HaveOccurred())
+	sqliteDB := xzCompressed(string(primarySQLite(mockPackages...)))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(mockPrimaryDBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(gzipped(primaryXML(mockPackages...)))
+	})
+	mux.HandleFunc(mockPrimaryZstdDBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(zstdCompressed(primaryXML(mockPackages...)))
+	})
+	mux.HandleFunc(mockPrimaryXzDBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(xzCompressed(primaryXML(mockPackages...)))
+	})
+	mux.HandleFunc(mockPrimaryBz2DBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(bz2DB)
+	})
+	mux.HandleFunc(mockPrimarySQLitePath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(sqliteDB)
+	})
+	mux.HandleFunc(mockFilelistsDBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(gzipped(filelistsXML(mockPackages...)))
+	})
+	mux.HandleFunc(mockRepoMetadataPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(repoMetadataXML()))
+	})
+	mux.HandleFunc(mockXMLRepoMetadataPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(xmlRepoMetadataXML()))
+	})
+	mux.HandleFunc(mockPrimaryRawDBPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(gzipped(primaryXML(mockPackages...)))
+	})
+
+	s := httptest.NewServer(mux)
+	DeferCleanup(s.Close)
+
+	return s
+}
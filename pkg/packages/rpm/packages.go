@@ -1,22 +1,21 @@
 package rpm
 
 import (
-	"compress/gzip"
 	"context"
 	"encoding/xml"
 	"github.com/antchfx/xmlquery"
 	"github.com/pkg/errors"
-	"golang.org/x/sys/unix"
 	"io"
-	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/maxgio92/linux-packages/internal/compression"
 	"github.com/maxgio92/linux-packages/internal/network"
 	"github.com/maxgio92/linux-packages/pkg/packages"
 )
@@ -30,6 +29,7 @@ type Package struct {
 	Description string          `xml:"description"`
 	Packager    string          `xml:"packager"`
 	Time        PackageTime     `xml:"time"`
+	Checksum    PackageChecksum `xml:"checksum"`
 	Size        PackageSize     `xml:"size"`
 	Location    PackageLocation `xml:"location"`
 	Format      PackageFormat   `xml:"format"`
@@ -49,6 +49,12 @@ type PackageTime struct {
 	Build string `xml:"build,attr"`
 }
 
+type PackageChecksum struct {
+	Type  string `xml:"type,attr"`
+	PkgID string `xml:"pkgid,attr"`
+	Value string `xml:",chardata"`
+}
+
 type PackageSize struct {
 	Package   string `xml:"package,attr"`
 	Installed string `xml:"installed,attr"`
@@ -58,6 +64,7 @@ type PackageSize struct {
 type PackageLocation struct {
 	XMLName xml.Name `xml:"location"`
 	Href    string   `xml:"href,attr"`
+	Base    string   `xml:"base,attr"`
 }
 
 type PackageFormat struct {
@@ -66,6 +73,7 @@ type PackageFormat struct {
 	Vendor      string             `xml:"vendor"`
 	Group       string             `xml:"group"`
 	Buildhost   string             `xml:"buildhost"`
+	SourceRPM   string             `xml:"sourcerpm"`
 	HeaderRange PackageHeaderRange `xml:"header-range"`
 	Requires    PackageRequires    `xml:"requires"`
 	Provides    PackageProvides    `xml:"provides"`
@@ -89,13 +97,69 @@ type PackageRequires struct {
 type Entry struct {
 	XMLName xml.Name `xml:"entry"`
 	Name    string   `xml:"name,attr"`
+	Flags   string   `xml:"flags,attr"`
+	Epoch   string   `xml:"epoch,attr"`
+	Ver     string   `xml:"ver,attr"`
+	Rel     string   `xml:"rel,attr"`
+}
+
+// EVR returns the epoch, version and release of the entry.
+// As rpm does, the EVR is parsed again from its string, since some entries
+// such as kernel-devel-uname-r carry the release in the version attribute.
+func (e Entry) EVR() EVR {
+	return ParseEVR(EVR{Epoch: e.Epoch, Version: e.Ver, Release: e.Rel}.String())
 }
 
 func (p *Package) Describe() string { return p.Description }
 
+// toPackage returns the generic package that matched the query,
+// found in the repository of the database at dbURL.
+func (p *Package) toPackage(query string, dbURL string) (*packages.Package, error) {
+	repo := repoURL(dbURL)
+
+	// The location base, when specified, overrides the repository as base of the href.
+	base := repo
+	if p.Location.Base != "" {
+		base = p.Location.Base
+	}
+
+	location, err := url.JoinPath(base, p.Location.Href)
+	if err != nil {
+		return nil, err
+	}
+
+	size, _ := strconv.ParseInt(p.Size.Package, 10, 64)
+	installedSize, _ := strconv.ParseInt(p.Size.Installed, 10, 64)
+
+	var buildTime time.Time
+	if t, err := strconv.ParseInt(p.Time.Build, 10, 64); err == nil && t > 0 {
+		buildTime = time.Unix(t, 0).UTC()
+	}
+
+	return packages.NewPackage(
+		packages.WithName(p.Name),
+		packages.WithQuery(query),
+		packages.WithEpoch(p.Version.Epoch),
+		packages.WithVersion(p.Version.Ver+"+"+p.Version.Rel),
+		packages.WithRelease(p.Version.Rel),
+		packages.WithLocation(location),
+		packages.WithArchitecture(p.Arch),
+		packages.WithChecksum(p.Checksum.Type, p.Checksum.Value),
+		packages.WithSize(size),
+		packages.WithInstalledSize(installedSize),
+		packages.WithBuildTime(buildTime),
+		packages.WithLicense(p.Format.License),
+		packages.WithVendor(p.Format.Vendor),
+		packages.WithSourcePackage(p.Format.SourceRPM),
+		packages.WithSummary(p.Summary),
+		packages.WithRepository(repo),
+	), nil
+}
+
 type PackageSearch struct {
-	names  []string
-	logger *log.Logger
+	names     []string
+	matchMode packages.MatchMode
+	logger    *log.Logger
 }
 
 type PackageSearchOption func(s *PackageSearch)
@@ -110,6 +174,14 @@ func WithPackageNames(names ...string) PackageSearchOption {
 	}
 }
 
+// WithPackageMatchMode sets how the names are matched against the names of the packages.
+// The names can be exact names (default), glob patterns or RE2 regular expressions.
+func WithPackageMatchMode(mode packages.MatchMode) PackageSearchOption {
+	return func(ps *PackageSearch) {
+		ps.matchMode = mode
+	}
+}
+
 func WithPackageLogger(logger *log.Logger) PackageSearchOption {
 	return func(ps *PackageSearch) {
 		ps.logger = logger
@@ -117,7 +189,7 @@ func WithPackageLogger(logger *log.Logger) PackageSearchOption {
 }
 
 func NewPackageSearcher(o ...PackageSearchOption) *PackageSearch {
-	ps := new(PackageSearch)
+	ps := &PackageSearch{logger: log.New()}
 	for _, f := range o {
 		f(ps)
 	}
@@ -130,142 +202,180 @@ func (ps *PackageSearch) validate() error {
 		return ErrSearchPackagaNameMissing
 	}
 
-	return nil
+	_, err := packages.NewNameMatcher(ps.matchMode, ps.names...)
+
+	return err
 }
 
-// TODO: avoid sending duplicate packages to che channel.
 func (ps *PackageSearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
 	destCh := make(chan *packages.Package)
-	if ps.validate() != nil {
+	if err := ps.validate(); err != nil {
+		ps.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "rpm.PackageSearch", sourceCh, err)
 		return destCh
 	}
 
-	wg := sync.WaitGroup{}
+	matcher, _ := packages.NewNameMatcher(ps.matchMode, ps.names...)
 
-	for source := range sourceCh {
-		source := source
-		ps.logger.WithField("database", source).Debug("receive")
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			pxml, err := ps.packagesXMLFromDB(ctx, source)
-			if err != nil {
-				return
-			}
-			repoURL, err := url.JoinPath(strings.Split(source, DirRepodata)[0], DirRepodata)
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			ps.logger.WithField("database", source).Debug("receive")
+			pxml, err := ps.packagesXMLFromDB(ctx, source, matcher)
 			if err != nil {
+				ps.logger.WithField("database", source).WithError(err).Debug("search")
+				reportError(ctx, "rpm.PackageSearch", source, err)
 				return
 			}
 			if pxml != nil {
 				for pkg := range packagesFromXML(ctx, pxml) {
 					pkg := pkg
-					pkgURL, err := url.JoinPath(repoURL, pkg.Location.Href)
+					query, ok := matcher.Match(pkg.Name)
+					if !ok {
+						continue
+					}
+					p, err := pkg.toPackage(query, source)
 					if err != nil {
-						break
+						reportError(ctx, "rpm.PackageSearch", source, err)
+						continue
+					}
+					ps.logger.WithField("package", p.Locate()).Debug("send")
+					if !packages.Send(ctx, destCh, p) {
+						return
 					}
-					ps.logger.WithField("package", pkgURL).Debug("send")
-					destCh <- packages.NewPackage(
-						packages.WithName(pkg.Name),
-						packages.WithVersion(pkg.Version.Ver+"+"+pkg.Version.Rel),
-						packages.WithLocation(pkgURL),
-						packages.WithArchitecture(pkg.Arch),
-					)
 				}
 			}
-		}()
-	}
-	go func() {
-		wg.Wait()
-		close(destCh)
+		})
 	}()
 
 	return destCh
 }
 
-func (ps *PackageSearch) packagesXMLFromDB(ctx context.Context, dbURL string) ([]*xmlquery.Node, error) {
-	if !strings.HasSuffix(path.Base(dbURL), ".xml.gz") {
+func (ps *PackageSearch) packagesXMLFromDB(ctx context.Context, dbURL string, matcher *packages.NameMatcher) ([]*xmlquery.Node, error) {
+	if !isSupportedDB(dbURL) {
 		return nil, ErrDBFormatNotSupported
 	}
 
 	if err := ps.validate(); err != nil {
 		return nil, err
 	}
-	name := ps.names[0]
 
-	u, err := url.Parse(dbURL)
+	filter := namesFilter(ps.names)
+	if matcher.Mode() != packages.MatchExact {
+		filter = expressionsFilter(matcher.Expressions())
+	}
+
+	return nodesFromXMLDB(ctx, dbURL, dataPackageXPath, dataPackageXPath+filter)
+}
+
+// nodesFromXMLDB returns the nodes of the XML database at dbURL that match elementXPath,
+// filtered by filterXPath, by stream-parsing the database.
+func nodesFromXMLDB(ctx context.Context, dbURL string, elementXPath string, filterXPath string) ([]*xmlquery.Node, error) {
+	resp, err := network.Get(ctx, dbURL)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	client := &http.Client{
-		Transport: network.DefaultClientTransport,
+	gr, err := compression.NewReader(resp.Body, dbURL)
+	if err != nil {
+		return nil, err
 	}
+	defer gr.Close()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	sp, err := xmlquery.CreateStreamParser(gr, elementXPath, filterXPath)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		if !errors.Is(err, unix.ECONNRESET) {
-			return nil, err
+	var nodes []*xmlquery.Node
+	for {
+		n, err := sp.Read()
+		if err == io.EOF {
+			break
 		}
-
-		// Dumb retry logic.
-		// TODO: implement smart cyclic retry with increasing backoff.
-		time.Sleep(1 * time.Second)
-		resp, err = client.Do(req)
 		if err != nil {
-			return nil, err
+			return nil, errors.Wrap(err, "error parsing the database")
 		}
+
+		nodes = append(nodes, n)
 	}
-	defer resp.Body.Close()
 
-	var packages []*xmlquery.Node
-	if resp.StatusCode == http.StatusOK && resp.Body != nil {
-		gr, err := gzip.NewReader(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		defer gr.Close()
+	return nodes, nil
+}
 
-		sp, err := xmlquery.CreateStreamParser(
-			gr,
-			dataPackageXPath,
-			dataPackageXPath+"[name='"+name+"']")
-		if err != nil {
-			return nil, err
-		}
+// isSupportedDB returns whether the database is in a supported format, that is XML.
+// The database can be compressed with any of the supported compression formats,
+// of which the decompressor is chosen from the extension or, when missing, from the content.
+func isSupportedDB(dbURL string) bool {
+	return path.Ext(compression.TrimExt(path.Base(dbURL))) != DBFormatSQLite
+}
 
-		for {
-			n, e := sp.Read()
-			if e != nil {
-				break
-			}
+// repoURL returns the URL of the root of the repository of the database at dbURL,
+// that is the parent of the repodata directory.
+func repoURL(dbURL string) string {
+	if i := strings.LastIndex(dbURL, "/"+DirRepodata+"/"); i >= 0 {
+		return dbURL[:i+1]
+	}
 
-			packages = append(packages, n)
-		}
+	return dbURL
+}
+
+// namesFilter returns an XPath predicate that matches the packages of which
+// the name is equal to any of the specified names, so that a single pass over
+// the database is enough to search for all of them.
+func namesFilter(names []string) string {
+	predicates := make([]string, 0, len(names))
+	for _, v := range names {
+		predicates = append(predicates, "name="+xpathLiteral(v))
+	}
+
+	return "[" + strings.Join(predicates, " or ") + "]"
+}
+
+// expressionsFilter returns an XPath predicate that matches the packages of which
+// the name matches any of the specified regular expressions.
+func expressionsFilter(expressions []string) string {
+	predicates := make([]string, 0, len(expressions))
+	for _, v := range expressions {
+		predicates = append(predicates, "matches(name, "+xpathLiteral(v)+")")
 	}
 
-	return packages, nil
+	return "[" + strings.Join(predicates, " or ") + "]"
 }
 
-func packagesFromXML(_ context.Context, nodes []*xmlquery.Node) chan *Package {
+// xpathLiteral returns the XPath 1.0 string literal of s.
+// XPath 1.0 has no escape sequences, so the quote that is not contained in s
+// is used as delimiter, falling back to concat() when s contains both.
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+
+	return "concat('" + strings.ReplaceAll(s, "'", `', "'", '`) + "')"
+}
+
+func packagesFromXML(ctx context.Context, nodes []*xmlquery.Node) chan *Package {
 	wg := sync.WaitGroup{}
 	wg.Add(len(nodes))
 
 	outCh := make(chan *Package)
 
-	for k, _ := range nodes {
+	for k := range nodes {
+		k := k
 		go func() {
 			defer wg.Done()
 
 			pkg := &Package{}
 
 			err := xml.Unmarshal([]byte(nodes[k].OutputXML(true)), pkg)
-			if err == nil {
-				outCh <- pkg
+			if err == nil && !packages.Send(ctx, outCh, pkg) {
+				return
 			}
 		}()
 	}
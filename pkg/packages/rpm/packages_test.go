@@ -4,6 +4,7 @@ package rpm_test
 
 import (
 	"context"
+	"net/http/httptest"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -88,3 +89,123 @@ var _ = Describe("Packages search", func() {
 		})
 	})
 })
+
+var _ = Describe("Packages search mock", func() {
+	var (
+		search *rpm.PackageSearch
+		ctx    = context.Background()
+	)
+
+	Context("With multiple names", func() {
+		BeforeEach(func() {
+			search = rpm.NewPackageSearcher(
+				rpm.WithPackageNames("kernel-devel", "kernel-headers", "kernel-core"),
+			)
+		})
+		Context("with seed URLs", Ordered, func() {
+			var (
+				sourceCh = make(chan string)
+				destCh   = make(chan *packages.Package)
+				actual   []*packages.Package
+				m        *httptest.Server
+			)
+			BeforeAll(func() {
+				m = runMockRepository()
+
+				// Test producer.
+				go func() {
+					sourceCh <- m.URL + mockPrimaryDBPath
+					close(sourceCh)
+				}()
+
+				// Stage.
+				destCh = search.Run(ctx, sourceCh)
+
+				// Test sink.
+				for v := range destCh {
+					actual = append(actual, v)
+				}
+			})
+			It("Should not fail", func() {
+				Expect(destCh).ToNot(BeNil())
+			})
+			It("Should stage results for all the names in one pass", func() {
+				Expect(len(actual)).To(Equal(4))
+			})
+			It("Should stage the name each result matched", func() {
+				for _, v := range actual {
+					Expect(v.Query()).To(Equal(v.Describe()))
+				}
+			})
+			It("Should stage the package metadata", func() {
+				for _, v := range actual {
+					expectMockMetadata(v, m.URL)
+				}
+			})
+		})
+	})
+
+	DescribeTable("With compressed database",
+		func(dbPath string) {
+			var actual []*packages.Package
+
+			search = rpm.NewPackageSearcher(
+				rpm.WithPackageNames("kernel-devel"),
+			)
+			m := runMockRepository()
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- m.URL + dbPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range search.Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			Expect(len(actual)).To(Equal(2))
+		},
+		Entry("gzip", mockPrimaryDBPath),
+		Entry("zstd", mockPrimaryZstdDBPath),
+		Entry("xz", mockPrimaryXzDBPath),
+		Entry("bzip2", mockPrimaryBz2DBPath),
+		Entry("without the compression extension", mockPrimaryRawDBPath),
+	)
+
+	DescribeTable("With match mode",
+		func(mode packages.MatchMode, names []string, expected int) {
+			var actual []*packages.Package
+
+			search = rpm.NewPackageSearcher(
+				rpm.WithPackageNames(names...),
+				rpm.WithPackageMatchMode(mode),
+			)
+			m := runMockRepository()
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- m.URL + mockPrimaryDBPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range search.Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			Expect(len(actual)).To(Equal(expected))
+			for _, v := range actual {
+				Expect(names).To(ContainElement(v.Query()))
+			}
+		},
+		Entry("glob", packages.MatchGlob, []string{"kernel-*"}, 4),
+		Entry("glob with more patterns", packages.MatchGlob, []string{"kernel-de*", "vim-?ommon"}, 3),
+		Entry("regex", packages.MatchRegex, []string{"kernel-(core|headers)"}, 2),
+		Entry("regex matching the whole name", packages.MatchRegex, []string{"vim"}, 0),
+		Entry("malformed glob", packages.MatchGlob, []string{"kernel-["}, 0),
+	)
+})
@@ -0,0 +1,99 @@
+package rpm
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// PrimarySearch is a pipeline stage that searches packages by name in the primary databases
+// of the repositories. The SQLite primary database (primary_db) is preferred, as it is looked up
+// instead of being parsed whole, and the XML one is searched in the repositories that do not provide it.
+type PrimarySearch struct {
+	names     []string
+	matchMode packages.MatchMode
+	logger    *log.Logger
+}
+
+type PrimarySearchOption func(s *PrimarySearch)
+
+// WithPrimaryNames sets the names of the packages to search for.
+func WithPrimaryNames(names ...string) PrimarySearchOption {
+	return func(search *PrimarySearch) {
+		search.names = names
+	}
+}
+
+// WithPrimaryMatchMode sets how the names are matched against the names of the packages.
+// The names can be exact names (default), glob patterns or RE2 regular expressions.
+func WithPrimaryMatchMode(mode packages.MatchMode) PrimarySearchOption {
+	return func(search *PrimarySearch) {
+		search.matchMode = mode
+	}
+}
+
+func WithPrimaryLogger(logger *log.Logger) PrimarySearchOption {
+	return func(search *PrimarySearch) {
+		search.logger = logger
+	}
+}
+
+func NewPrimarySearcher(o ...PrimarySearchOption) *PrimarySearch {
+	ps := &PrimarySearch{logger: log.New()}
+	for _, f := range o {
+		f(ps)
+	}
+
+	return ps
+}
+
+// Run runs a pipeline stage of which the output is a channel of packages.
+// The source of the stage is a channel of repository metadata (repomd) URL strings.
+func (ps *PrimarySearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
+	dbs := NewDBSearcher(
+		WithDBTypes(DBTypePrimaryDB, DBTypePrimary),
+		WithDBLogger(ps.logger),
+	).Run(ctx, sourceCh)
+
+	sqliteDBs, xmlDBs := splitDBs(ctx, dbs)
+
+	return packages.Merge(ctx,
+		NewSQLiteSearcher(
+			WithSQLiteNames(ps.names...),
+			WithSQLiteMatchMode(ps.matchMode),
+			WithSQLiteLogger(ps.logger),
+		).Run(ctx, sqliteDBs),
+		NewPackageSearcher(
+			WithPackageNames(ps.names...),
+			WithPackageMatchMode(ps.matchMode),
+			WithPackageLogger(ps.logger),
+		).Run(ctx, xmlDBs),
+	)
+}
+
+// splitDBs splits the stream of database URLs into the streams of the SQLite
+// and of the XML databases.
+func splitDBs(ctx context.Context, dbs chan string) (chan string, chan string) {
+	sqliteCh := make(chan string)
+	xmlCh := make(chan string)
+
+	go func() {
+		defer close(sqliteCh)
+		defer close(xmlCh)
+
+		for v := range dbs {
+			destCh := xmlCh
+			if isSQLiteDB(v) {
+				destCh = sqliteCh
+			}
+			if !packages.Send(ctx, destCh, v) {
+				packages.Drain(dbs)
+				return
+			}
+		}
+	}()
+
+	return sqliteCh, xmlCh
+}
@@ -0,0 +1,78 @@
+//go:build all_tests || all_unit_tests || (unit_tests && primary && rpm)
+
+package rpm_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+var _ = Describe("Primary search mock", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	DescribeTable("With repository metadata",
+		func(repoMetadataPath string, expectedDBPath string) {
+			var (
+				actual    []*packages.Package
+				mu        sync.Mutex
+				requested []string
+			)
+
+			m := runMockRepository()
+			target, err := url.Parse(m.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			// The proxy records the databases requested to the mock repository.
+			proxy := httputil.NewSingleHostReverseProxy(target)
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requested = append(requested, r.URL.Path)
+				mu.Unlock()
+				proxy.ServeHTTP(w, r)
+			}))
+			DeferCleanup(s.Close)
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- s.URL + repoMetadataPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range rpm.NewPrimarySearcher(
+				rpm.WithPrimaryNames("kernel-de*"),
+				rpm.WithPrimaryMatchMode(packages.MatchGlob),
+			).Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			Expect(actual).To(HaveLen(2))
+			for _, v := range actual {
+				Expect(v.Query()).To(Equal("kernel-de*"))
+				expectMockMetadata(v, s.URL)
+			}
+			Expect(requested).To(ConsistOf(repoMetadataPath, expectedDBPath))
+		},
+		Entry("listing the SQLite database should search it",
+			mockRepoMetadataPath,
+			mockPrimarySQLitePath,
+		),
+		Entry("listing the XML database only should fall back to it",
+			mockXMLRepoMetadataPath,
+			mockPrimaryDBPath,
+		),
+	)
+})
@@ -3,9 +3,11 @@ package rpm
 import (
 	"context"
 	log "github.com/sirupsen/logrus"
-	"sync"
 
 	wfind "github.com/maxgio92/wfind/pkg/find"
+
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
 )
 
 type RepoSearcher struct {
@@ -25,7 +27,7 @@ func WithRepoLogger(logger *log.Logger) RepoSearchOption {
 }
 
 func NewRepoSearcher(o ...RepoSearchOption) *RepoSearcher {
-	rs := new(RepoSearcher)
+	rs := &RepoSearcher{logger: log.New()}
 	for _, f := range o {
 		f(rs)
 	}
@@ -33,17 +35,14 @@ func NewRepoSearcher(o ...RepoSearchOption) *RepoSearcher {
 	return rs
 }
 
-func (rs *RepoSearcher) Run(_ context.Context, sourceCh chan string) chan string {
+func (rs *RepoSearcher) Run(ctx context.Context, sourceCh chan string) chan string {
 	destCh := make(chan string)
 
-	wg := sync.WaitGroup{}
+	go func() {
+		defer close(destCh)
 
-	for source := range sourceCh {
-		source := source
-		rs.logger.WithField("mirror", source).Debug("receive")
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			rs.logger.WithField("mirror", source).Debug("receive")
 			finder := wfind.NewFind(
 				wfind.WithSeedURLs([]string{source}),
 				wfind.WithFilenameRegexp(Repomd),
@@ -53,24 +52,24 @@ func (rs *RepoSearcher) Run(_ context.Context, sourceCh chan string) chan string
 				wfind.WithContextDeadlineRetryBackOff(wfind.DefaultExponentialBackOffOptions),
 				wfind.WithConnResetRetryBackOff(wfind.DefaultExponentialBackOffOptions),
 				wfind.WithConnTimeoutRetryBackOff(wfind.DefaultExponentialBackOffOptions),
+				wfind.WithClientTransport(network.FindTransport(ctx)),
 			)
 
-			found, err := finder.Find()
+			found, err := network.Find(ctx, finder)
 			if err != nil {
 				rs.logger.WithError(err).Warn("error searching repositories")
+				packages.ReportError(ctx, "rpm.RepoSearcher", source, err)
 			}
 			if found != nil {
 				for _, v := range found.URLs {
 					v := v
 					rs.logger.WithField("repository", v).Debug("send")
-					destCh <- v
+					if !packages.Send(ctx, destCh, v) {
+						return
+					}
 				}
 			}
-		}()
-	}
-	go func() {
-		wg.Wait()
-		close(destCh)
+		})
 	}()
 
 	return destCh
@@ -0,0 +1,313 @@
+package rpm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+
+	"github.com/maxgio92/linux-packages/internal/compression"
+	"github.com/maxgio92/linux-packages/internal/network"
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+const (
+	sqliteDriver = "sqlite"
+
+	// DISTINCT drops the duplicate rows of the packages that provide the same capability more times.
+	packagesSelect = `SELECT DISTINCT p.name, p.arch, COALESCE(p.epoch, ''), p.version, p.release,` +
+		` p.pkgId, COALESCE(p.checksum_type, ''), COALESCE(p.summary, ''),` +
+		` COALESCE(p.size_package, 0), COALESCE(p.size_installed, 0), COALESCE(p.time_build, 0),` +
+		` COALESCE(p.rpm_license, ''), COALESCE(p.rpm_vendor, ''), COALESCE(p.rpm_sourcerpm, ''),` +
+		` p.location_href, COALESCE(p.location_base, ''), %s FROM packages p`
+)
+
+// SQLiteSearch is a pipeline stage that searches packages in the SQLite primary databases
+// (primary_db), by running indexed lookups instead of parsing the whole database.
+type SQLiteSearch struct {
+	names     []string
+	matchMode packages.MatchMode
+	provides  []string
+	archs     []string
+	logger    *log.Logger
+}
+
+type SQLiteSearchOption func(s *SQLiteSearch)
+
+// WithSQLiteNames sets the names of the packages to search for.
+func WithSQLiteNames(names ...string) SQLiteSearchOption {
+	return func(search *SQLiteSearch) {
+		search.names = names
+	}
+}
+
+// WithSQLiteMatchMode sets how the names are matched against the names of the packages.
+// The names can be exact names (default), looked up through the index, or glob patterns
+// and RE2 regular expressions, matched against the names of all the packages of the database.
+func WithSQLiteMatchMode(mode packages.MatchMode) SQLiteSearchOption {
+	return func(search *SQLiteSearch) {
+		search.matchMode = mode
+	}
+}
+
+// WithSQLiteProvides sets the names of the capabilities provided by the packages to search for.
+func WithSQLiteProvides(provides ...string) SQLiteSearchOption {
+	return func(search *SQLiteSearch) {
+		search.provides = provides
+	}
+}
+
+// WithSQLiteArchs restricts the search to the packages of the specified architectures.
+func WithSQLiteArchs(archs ...string) SQLiteSearchOption {
+	return func(search *SQLiteSearch) {
+		search.archs = archs
+	}
+}
+
+func WithSQLiteLogger(logger *log.Logger) SQLiteSearchOption {
+	return func(search *SQLiteSearch) {
+		search.logger = logger
+	}
+}
+
+func NewSQLiteSearcher(o ...SQLiteSearchOption) *SQLiteSearch {
+	ss := &SQLiteSearch{logger: log.New()}
+	for _, f := range o {
+		f(ss)
+	}
+
+	return ss
+}
+
+func (ss *SQLiteSearch) validate() error {
+	if len(ss.names) == 0 && len(ss.provides) == 0 {
+		return ErrSearchPackagaNameMissing
+	}
+
+	_, err := packages.NewNameMatcher(ss.matchMode, ss.names...)
+
+	return err
+}
+
+// Run runs a pipeline stage of which the output is a channel of packages.
+// The source of the stage is a channel of SQLite primary database URL strings.
+// Databases in other formats are skipped.
+func (ss *SQLiteSearch) Run(ctx context.Context, sourceCh chan string) chan *packages.Package {
+	destCh := make(chan *packages.Package)
+	if err := ss.validate(); err != nil {
+		ss.logger.WithError(err).Error("validate")
+		close(destCh)
+		packages.DrainInvalid(ctx, "rpm.SQLiteSearch", sourceCh, err)
+		return destCh
+	}
+
+	matcher, _ := packages.NewNameMatcher(ss.matchMode, ss.names...)
+
+	go func() {
+		defer close(destCh)
+
+		packages.ForEach(ctx, sourceCh, func(source string) {
+			ss.logger.WithField("database", source).Debug("receive")
+			pkgs, err := ss.packagesFromDB(ctx, source, matcher)
+			if err != nil {
+				ss.logger.WithField("database", source).WithError(err).Debug("search")
+				reportError(ctx, "rpm.SQLiteSearch", source, err)
+				return
+			}
+			for _, pkg := range pkgs {
+				ss.logger.WithField("package", pkg.Locate()).Debug("send")
+				if !packages.Send(ctx, destCh, pkg) {
+					return
+				}
+			}
+		})
+	}()
+
+	return destCh
+}
+
+func (ss *SQLiteSearch) packagesFromDB(ctx context.Context, dbURL string, matcher *packages.NameMatcher) ([]*packages.Package, error) {
+	if !isSQLiteDB(dbURL) {
+		return nil, ErrDBFormatNotSupported
+	}
+
+	// The SQLite database needs to be on a file in order to be opened.
+	dbFile, err := downloadDB(ctx, dbURL)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(dbFile)
+
+	db, err := sql.Open(sqliteDriver, "file:"+dbFile+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var pkgs []*packages.Package
+
+	// The packages that matched the same name by name and by provides are returned once.
+	seen := make(map[[2]string]bool)
+	for _, q := range ss.queries() {
+		found, err := packagesFromQuery(ctx, db, q, matcher, dbURL)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range found {
+			key := [2]string{p.Locate(), p.Query()}
+			if !seen[key] {
+				seen[key] = true
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+
+	return pkgs, nil
+}
+
+// sqliteQuery is a query that selects packages, each along with what it matched.
+type sqliteQuery struct {
+	query string
+	args  []interface{}
+
+	// byName is whether the packages are selected by name, in which case
+	// they are matched against the names with the matcher.
+	byName bool
+}
+
+// packagesFromQuery returns the packages selected by the query q.
+func packagesFromQuery(ctx context.Context, db *sql.DB, q sqliteQuery, matcher *packages.NameMatcher, dbURL string) ([]*packages.Package, error) {
+	rows, err := db.QueryContext(ctx, q.query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pkgs []*packages.Package
+	for rows.Next() {
+		var (
+			pkg                            Package
+			size, installedSize, buildTime int64
+			match                          string
+		)
+		if err = rows.Scan(
+			&pkg.Name, &pkg.Arch, &pkg.Version.Epoch, &pkg.Version.Ver, &pkg.Version.Rel,
+			&pkg.Checksum.Value, &pkg.Checksum.Type, &pkg.Summary,
+			&size, &installedSize, &buildTime,
+			&pkg.Format.License, &pkg.Format.Vendor, &pkg.Format.SourceRPM,
+			&pkg.Location.Href, &pkg.Location.Base, &match,
+		); err != nil {
+			return nil, err
+		}
+		if q.byName {
+			var ok bool
+			if match, ok = matcher.Match(pkg.Name); !ok {
+				continue
+			}
+		}
+		pkg.Size.Package = strconv.FormatInt(size, 10)
+		pkg.Size.Installed = strconv.FormatInt(installedSize, 10)
+		pkg.Time.Build = strconv.FormatInt(buildTime, 10)
+
+		p, err := pkg.toPackage(match, dbURL)
+		if err != nil {
+			continue
+		}
+		pkgs = append(pkgs, p)
+	}
+
+	return pkgs, rows.Err()
+}
+
+// queries returns the SQL queries that select the packages matching the names and the provides.
+// The packages are selected by name through the index only when the names are exact,
+// otherwise all of them are selected and matched against the patterns.
+func (ss *SQLiteSearch) queries() []sqliteQuery {
+	var queries []sqliteQuery
+
+	if len(ss.names) > 0 {
+		q := sqliteQuery{query: fmt.Sprintf(packagesSelect, "p.name") + " WHERE 1 = 1", byName: true}
+		if ss.matchMode == "" || ss.matchMode == packages.MatchExact {
+			q.query += " AND p.name IN (" + placeholders(len(ss.names)) + ")"
+			q.args = toArgs(ss.names)
+		}
+		q.query, q.args = ss.archsFilter(q.query, q.args)
+		queries = append(queries, q)
+	}
+
+	if len(ss.provides) > 0 {
+		q := sqliteQuery{
+			query: fmt.Sprintf(packagesSelect, "pr.name") +
+				" JOIN provides pr ON pr.pkgKey = p.pkgKey" +
+				" WHERE pr.name IN (" + placeholders(len(ss.provides)) + ")",
+			args: toArgs(ss.provides),
+		}
+		q.query, q.args = ss.archsFilter(q.query, q.args)
+		queries = append(queries, q)
+	}
+
+	return queries
+}
+
+func (ss *SQLiteSearch) archsFilter(query string, args []interface{}) (string, []interface{}) {
+	if len(ss.archs) == 0 {
+		return query, args
+	}
+
+	return query + " AND p.arch IN (" + placeholders(len(ss.archs)) + ")", append(args, toArgs(ss.archs)...)
+}
+
+// downloadDB downloads and decompresses the database at dbURL into a temporary file,
+// and returns its path.
+func downloadDB(ctx context.Context, dbURL string) (string, error) {
+	resp, err := network.Get(ctx, dbURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	r, err := compression.NewReader(resp.Body, dbURL)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	f, err := os.CreateTemp("", "primary-*"+DBFormatSQLite)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(f, r); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// isSQLiteDB returns whether the database is in the SQLite format,
+// compressed with any of the supported compression formats.
+func isSQLiteDB(dbURL string) bool {
+	return path.Ext(compression.TrimExt(path.Base(dbURL))) == DBFormatSQLite
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func toArgs(values []string) []interface{} {
+	args := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		args = append(args, v)
+	}
+
+	return args
+}
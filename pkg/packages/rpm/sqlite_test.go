@@ -0,0 +1,120 @@
+//go:build all_tests || all_unit_tests || (unit_tests && sqlite && rpm)
+
+package rpm_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+var _ = Describe("SQLite search mock", func() {
+	var (
+		ctx = context.Background()
+	)
+
+	DescribeTable("With SQLite primary database",
+		func(dbPath string, options []rpm.SQLiteSearchOption, expected int) {
+			var actual []*packages.Package
+
+			m := runMockRepository()
+
+			// Test producer.
+			sourceCh := make(chan string)
+			go func() {
+				sourceCh <- m.URL + dbPath
+				close(sourceCh)
+			}()
+
+			// Stage and test sink.
+			for v := range rpm.NewSQLiteSearcher(options...).Run(ctx, sourceCh) {
+				actual = append(actual, v)
+			}
+
+			Expect(len(actual)).To(Equal(expected))
+			for _, v := range actual {
+				expectMockMetadata(v, m.URL)
+			}
+		},
+		Entry("by names",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-devel", "vim-common")},
+			3,
+		),
+		Entry("by provides",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteProvides("kernel-devel")},
+			2,
+		),
+		Entry("by names and provides",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-core"), rpm.WithSQLiteProvides("kernel-headers")},
+			2,
+		),
+		Entry("by glob patterns",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-*"), rpm.WithSQLiteMatchMode(packages.MatchGlob)},
+			4,
+		),
+		Entry("by regular expressions",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-(core|headers)"), rpm.WithSQLiteMatchMode(packages.MatchRegex)},
+			2,
+		),
+		Entry("by glob patterns of missing architectures",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{
+				rpm.WithSQLiteNames("kernel-*"),
+				rpm.WithSQLiteMatchMode(packages.MatchGlob),
+				rpm.WithSQLiteArchs("aarch64"),
+			},
+			0,
+		),
+		Entry("by names of missing architectures",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-devel"), rpm.WithSQLiteArchs("aarch64")},
+			0,
+		),
+		Entry("by names matching by name and by provides",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-core"), rpm.WithSQLiteProvides("kernel-core")},
+			1,
+		),
+		Entry("with an invalid regular expression",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-("), rpm.WithSQLiteMatchMode(packages.MatchRegex)},
+			0,
+		),
+		Entry("without names",
+			mockPrimarySQLitePath,
+			[]rpm.SQLiteSearchOption{},
+			0,
+		),
+		Entry("with XML database",
+			mockPrimaryDBPath,
+			[]rpm.SQLiteSearchOption{rpm.WithSQLiteNames("kernel-devel")},
+			0,
+		),
+	)
+
+	It("Should report the invalid options", func() {
+		c := packages.NewErrorCollector()
+		ctx := packages.WithErrorCollector(ctx, c)
+
+		sourceCh := make(chan string, 2)
+		sourceCh <- "https://mirror.example.com/repodata/primary.sqlite.bz2"
+		sourceCh <- "https://mirror.example.com/repodata/other.sqlite.bz2"
+		close(sourceCh)
+		Eventually(rpm.NewSQLiteSearcher().Run(ctx, sourceCh)).Should(BeClosed())
+
+		Eventually(c.Errors).Should(HaveLen(1))
+		Expect(c.Errors()[0].Stage).To(Equal("rpm.SQLiteSearch"))
+		Expect(c.Errors()[0].URL).To(Equal("https://mirror.example.com/repodata/primary.sqlite.bz2"))
+		Expect(c.Errors()[0]).To(MatchError(rpm.ErrSearchPackagaNameMissing))
+		Consistently(c.Errors).Should(HaveLen(1))
+	})
+})
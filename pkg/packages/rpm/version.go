@@ -0,0 +1,227 @@
+package rpm
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+// EVR is the epoch, version and release of a package or of a capability.
+type EVR struct {
+	Epoch   string
+	Version string
+	Release string
+}
+
+// ParseEVR parses a [epoch:]version[-release] string.
+func ParseEVR(s string) EVR {
+	var evr EVR
+
+	if i := strings.Index(s, ":"); i >= 0 {
+		evr.Epoch, s = s[:i], s[i+1:]
+	}
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		s, evr.Release = s[:i], s[i+1:]
+	}
+	evr.Version = s
+
+	return evr
+}
+
+// String returns the [epoch:]version[-release] representation of the EVR.
+func (e EVR) String() string {
+	s := e.Version
+	if e.Epoch != "" {
+		s = e.Epoch + ":" + s
+	}
+	if e.Release != "" {
+		s += "-" + e.Release
+	}
+
+	return s
+}
+
+// Compare returns -1, 0 or 1 whether e is older, equal or newer than o.
+// A missing epoch is equal to 0, and the releases are compared only when both
+// are specified, so that a version without release matches all its releases.
+func (e EVR) Compare(o EVR) int {
+	if rc := Vercmp(epochOrZero(e.Epoch), epochOrZero(o.Epoch)); rc != 0 {
+		return rc
+	}
+	if rc := Vercmp(e.Version, o.Version); rc != 0 {
+		return rc
+	}
+	if e.Release == "" || o.Release == "" {
+		return 0
+	}
+
+	return Vercmp(e.Release, o.Release)
+}
+
+// PackageEVR returns the EVR of a package emitted by the searches.
+func PackageEVR(p *packages.Package) EVR {
+	return EVR{
+		Epoch:   p.Epoch(),
+		Version: strings.TrimSuffix(p.Version(), "+"+p.Release()),
+		Release: p.Release(),
+	}
+}
+
+// Constraint is a constraint on the versions of packages, such as ">= 5.14" or "< 6".
+type Constraint struct {
+	Flags string
+	EVR   EVR
+}
+
+// ParseConstraint parses a version constraint in the form [operator] [epoch:]version[-release],
+// where the operator is one of <, <=, =, >=, >, or one of the flags LT, LE, EQ, GE, GT.
+// A version without operator is an equality constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	fields := strings.Fields(s)
+
+	switch len(fields) {
+	case 1:
+		return &Constraint{Flags: FlagEQ, EVR: ParseEVR(fields[0])}, nil
+	case 2:
+		flags, ok := parseFlags(fields[0])
+		if !ok {
+			return nil, errors.Wrap(ErrConstraintMalformed, s)
+		}
+
+		return &Constraint{Flags: flags, EVR: ParseEVR(fields[1])}, nil
+	default:
+		return nil, errors.Wrap(ErrConstraintMalformed, s)
+	}
+}
+
+// Match returns whether the EVR satisfies the constraint.
+func (c *Constraint) Match(evr EVR) bool {
+	return rangesOverlap(FlagEQ, evr, c.Flags, c.EVR)
+}
+
+func epochOrZero(epoch string) string {
+	if epoch == "" {
+		return "0"
+	}
+
+	return epoch
+}
+
+// Vercmp compares two version or release strings with the rpmvercmp algorithm,
+// and returns -1, 0 or 1 whether a is older, equal or newer than b.
+// The strings are compared by segments of digits or letters, ignoring the other separators.
+// Numeric segments are newer than alphabetic ones, a tilde sorts before anything,
+// even the end of the string, and a caret after the end of the string but before anything else.
+func Vercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	one, two := a, b
+
+	for len(one) > 0 || len(two) > 0 {
+		one = strings.TrimLeftFunc(one, isVersionSeparator)
+		two = strings.TrimLeftFunc(two, isVersionSeparator)
+
+		if strings.HasPrefix(one, "~") || strings.HasPrefix(two, "~") {
+			if !strings.HasPrefix(one, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(two, "~") {
+				return -1
+			}
+			one, two = one[1:], two[1:]
+			continue
+		}
+
+		if strings.HasPrefix(one, "^") || strings.HasPrefix(two, "^") {
+			if len(one) == 0 {
+				return -1
+			}
+			if len(two) == 0 {
+				return 1
+			}
+			if !strings.HasPrefix(one, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(two, "^") {
+				return -1
+			}
+			one, two = one[1:], two[1:]
+			continue
+		}
+
+		if len(one) == 0 || len(two) == 0 {
+			break
+		}
+
+		isNum := isDigit(rune(one[0]))
+		segment := isAlpha
+		if isNum {
+			segment = isDigit
+		}
+
+		seg1, rest1 := splitSegment(one, segment)
+		seg2, rest2 := splitSegment(two, segment)
+
+		// The segments are of different types, as the second is empty.
+		if len(seg2) == 0 {
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+
+		if isNum {
+			seg1 = strings.TrimLeft(seg1, "0")
+			seg2 = strings.TrimLeft(seg2, "0")
+
+			if len(seg1) > len(seg2) {
+				return 1
+			}
+			if len(seg2) > len(seg1) {
+				return -1
+			}
+		}
+
+		if rc := strings.Compare(seg1, seg2); rc != 0 {
+			return rc
+		}
+
+		one, two = rest1, rest2
+	}
+
+	if len(one) == 0 && len(two) == 0 {
+		return 0
+	}
+	if len(one) == 0 {
+		return -1
+	}
+
+	return 1
+}
+
+func splitSegment(s string, f func(rune) bool) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool { return !f(r) })
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:i], s[i:]
+}
+
+func isVersionSeparator(r rune) bool {
+	return !isDigit(r) && !isAlpha(r) && r != '~' && r != '^'
+}
+
+// isDigit and isAlpha match ASCII characters only, as rpm does.
+func isDigit(r rune) bool {
+	return r <= unicode.MaxASCII && unicode.IsDigit(r)
+}
+
+func isAlpha(r rune) bool {
+	return r <= unicode.MaxASCII && unicode.IsLetter(r)
+}
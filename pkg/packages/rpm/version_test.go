@@ -0,0 +1,164 @@
+//go:build all_tests || all_unit_tests || (unit_tests && version && rpm)
+
+package rpm_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+	"github.com/maxgio92/linux-packages/pkg/packages/rpm"
+)
+
+var _ = Describe("Version comparison", func() {
+	DescribeTable("Vercmp",
+		func(a, b string, expected int) {
+			Expect(rpm.Vercmp(a, b)).To(Equal(expected))
+			Expect(rpm.Vercmp(b, a)).To(Equal(-expected))
+		},
+		Entry(nil, "1.0", "1.0", 0),
+		Entry(nil, "1.0", "2.0", -1),
+		Entry(nil, "2.0.1", "2.0", 1),
+		Entry(nil, "2.0.1a", "2.0.1", 1),
+		Entry(nil, "5.5p1", "5.5p2", -1),
+		Entry(nil, "5.5p10", "5.5p2", 1),
+		Entry(nil, "10xyz", "10.1xyz", -1),
+		Entry(nil, "xyz10", "xyz10.1", -1),
+		Entry(nil, "1b", "1a", 1),
+		Entry(nil, "2_0", "2.0", 0),
+		Entry(nil, "1.010", "1.10", 0),
+		Entry(nil, "1.0a", "1.0.1", -1),
+		Entry(nil, "5.14.0", "5.14", 1),
+		Entry(nil, "362.el9", "284.el9", 1),
+		Entry(nil, "1.0", "1.0~rc1", 1),
+		Entry(nil, "1.0~rc1", "1.0~rc2", -1),
+		Entry(nil, "1.0~rc1~git123", "1.0~rc1", -1),
+		Entry(nil, "1.0^", "1.0", 1),
+		Entry(nil, "1.0^git1", "1.0", 1),
+		Entry(nil, "1.0^git1", "1.0.1", -1),
+		Entry(nil, "1.0^git1~pre", "1.0^git1", -1),
+	)
+
+	DescribeTable("EVR",
+		func(a, b string, expected int) {
+			Expect(rpm.ParseEVR(a).Compare(rpm.ParseEVR(b))).To(Equal(expected))
+		},
+		Entry(nil, "5.14.0-362.el9", "5.14.0-362.el9", 0),
+		Entry(nil, "5.14.0-362.el9", "5.14.0-284.el9", 1),
+		Entry(nil, "0:5.14.0-362.el9", "5.14.0-362.el9", 0),
+		Entry(nil, "1:4.18.0-1.el8", "5.14.0-362.el9", 1),
+		Entry(nil, "5.14.0", "5.14.0-284.el9", 0),
+		Entry(nil, "5.14", "5.14.0-284.el9", -1),
+	)
+
+	It("Should parse and format the EVR", func() {
+		evr := rpm.ParseEVR("2:5.14.0-362.8.1.el9_3")
+		Expect(evr).To(Equal(rpm.EVR{Epoch: "2", Version: "5.14.0", Release: "362.8.1.el9_3"}))
+		Expect(evr.String()).To(Equal("2:5.14.0-362.8.1.el9_3"))
+	})
+
+	DescribeTable("Constraint",
+		func(constraint, evr string, expected bool) {
+			c, err := rpm.ParseConstraint(constraint)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Match(rpm.ParseEVR(evr))).To(Equal(expected))
+		},
+		Entry(nil, ">= 5.14", "5.14.0-362.el9", true),
+		Entry(nil, ">= 5.14", "4.18.0-513.el8", false),
+		Entry(nil, "< 6", "5.14.0-362.el9", true),
+		Entry(nil, "< 6", "6.5.6-300.fc39", false),
+		Entry(nil, "> 5.14.0-284.el9", "5.14.0-362.el9", true),
+		Entry(nil, "> 5.14.0-362.el9", "5.14.0-362.el9", false),
+		Entry(nil, "<= 5.14.0", "5.14.0-362.el9", true),
+		Entry(nil, "= 5.14.0", "5.14.0-362.el9", true),
+		Entry(nil, "5.14.0-284.el9", "5.14.0-362.el9", false),
+		Entry(nil, "GE 1:4.18", "5.14.0-362.el9", false),
+	)
+
+	DescribeTable("Malformed constraint",
+		func(constraint string) {
+			_, err := rpm.ParseConstraint(constraint)
+			Expect(err).To(MatchError(rpm.ErrConstraintMalformed))
+		},
+		Entry(nil, ""),
+		Entry(nil, "~ 5.14"),
+		Entry(nil, ">= 5.14 6"),
+	)
+})
+
+var _ = Describe("Version filter", func() {
+	var (
+		ctx       = context.Background()
+		repo      = "https://mirror/repo/"
+		otherRepo = "https://mirror/other/"
+	)
+
+	newPackage := func(name, arch, ver, rel, repo string) *packages.Package {
+		return packages.NewPackage(
+			packages.WithName(name),
+			packages.WithArchitecture(arch),
+			packages.WithEpoch("0"),
+			packages.WithVersion(ver+"+"+rel),
+			packages.WithRelease(rel),
+			packages.WithRepository(repo),
+			packages.WithLocation(repo+"Packages/"+name+"-"+ver+"-"+rel+"."+arch+".rpm"),
+		)
+	}
+
+	source := []*packages.Package{
+		newPackage("kernel-devel", "x86_64", "5.14.0", "284.el9", repo),
+		newPackage("kernel-devel", "x86_64", "5.14.0", "362.el9", repo),
+		newPackage("kernel-devel", "x86_64", "5.14.0", "70.el9", repo),
+		newPackage("kernel-devel", "aarch64", "5.14.0", "284.el9", repo),
+		newPackage("kernel-devel", "x86_64", "4.18.0", "513.el8", otherRepo),
+		newPackage("kernel-devel", "x86_64", "6.5.6", "300.fc39", otherRepo),
+	}
+
+	DescribeTable("With packages",
+		func(options []rpm.VersionFilterOption, expected []string) {
+			sourceCh := make(chan *packages.Package)
+			go func() {
+				for _, v := range source {
+					sourceCh <- v
+				}
+				close(sourceCh)
+			}()
+
+			var actual []string
+			for v := range rpm.NewVersionFilter(options...).Run(ctx, sourceCh) {
+				actual = append(actual, v.Architecture()+"/"+rpm.PackageEVR(v).String())
+			}
+
+			Expect(actual).To(ConsistOf(expected))
+		},
+		Entry("without options",
+			[]rpm.VersionFilterOption{},
+			[]string{
+				"x86_64/0:5.14.0-284.el9", "x86_64/0:5.14.0-362.el9", "x86_64/0:5.14.0-70.el9",
+				"aarch64/0:5.14.0-284.el9", "x86_64/0:4.18.0-513.el8", "x86_64/0:6.5.6-300.fc39",
+			},
+		),
+		Entry("latest",
+			[]rpm.VersionFilterOption{rpm.WithVersionLatest(true)},
+			[]string{"x86_64/0:5.14.0-362.el9", "aarch64/0:5.14.0-284.el9", "x86_64/0:6.5.6-300.fc39"},
+		),
+		Entry("with constraints",
+			[]rpm.VersionFilterOption{rpm.WithVersionConstraints(">= 5.14", "< 6")},
+			[]string{"x86_64/0:5.14.0-284.el9", "x86_64/0:5.14.0-362.el9", "x86_64/0:5.14.0-70.el9", "aarch64/0:5.14.0-284.el9"},
+		),
+		Entry("latest with constraints",
+			[]rpm.VersionFilterOption{rpm.WithVersionLatest(true), rpm.WithVersionConstraints("< 6")},
+			[]string{"x86_64/0:5.14.0-362.el9", "aarch64/0:5.14.0-284.el9", "x86_64/0:4.18.0-513.el8"},
+		),
+		Entry("latest with constraints excluding the newest",
+			[]rpm.VersionFilterOption{rpm.WithVersionLatest(true), rpm.WithVersionConstraints("< 5.14.0-300")},
+			[]string{"x86_64/0:5.14.0-284.el9", "aarch64/0:5.14.0-284.el9", "x86_64/0:4.18.0-513.el8"},
+		),
+		Entry("with malformed constraints",
+			[]rpm.VersionFilterOption{rpm.WithVersionConstraints("~ 5")},
+			nil,
+		),
+	)
+})
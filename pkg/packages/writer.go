@@ -0,0 +1,358 @@
+package packages
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format in which the packages are written by a Writer.
+type Format string
+
+const (
+	// FormatNDJSON writes a JSON object per line.
+	FormatNDJSON Format = "ndjson"
+
+	// FormatJSON writes a JSON array of objects.
+	FormatJSON Format = "json"
+
+	// FormatYAML writes a YAML document per package.
+	FormatYAML Format = "yaml"
+
+	// FormatCSV writes a CSV record per package, after a header record.
+	FormatCSV Format = "csv"
+
+	// FormatTable writes a table aligned in columns, after a header row.
+	FormatTable Format = "table"
+)
+
+var (
+	// Formats are the formats supported by the Writer.
+	Formats = []Format{FormatNDJSON, FormatJSON, FormatYAML, FormatCSV, FormatTable}
+
+	// ResultColumns are the names of the fields of a Result,
+	// in the order of the CSV and table columns.
+	ResultColumns = []string{
+		"query",
+		"name",
+		"epoch",
+		"version",
+		"release",
+		"architecture",
+		"location",
+		"mirrors",
+		"checksumType",
+		"checksum",
+		"size",
+		"installedSize",
+		"buildTime",
+		"license",
+		"vendor",
+		"sourcePackage",
+		"summary",
+		"repository",
+	}
+
+	// tableColumns are the columns of the table, a subset of the
+	// ResultColumns that fits a terminal.
+	tableColumns = []string{"query", "name", "version", "architecture", "repository", "location"}
+)
+
+// Result is the representation of a package written by a Writer.
+// The fields are always written, empty when unknown, so that the
+// schema is the same for all the packages and the distros.
+type Result struct {
+	Query         string   `json:"query" yaml:"query"`
+	Name          string   `json:"name" yaml:"name"`
+	Epoch         string   `json:"epoch" yaml:"epoch"`
+	Version       string   `json:"version" yaml:"version"`
+	Release       string   `json:"release" yaml:"release"`
+	Architecture  string   `json:"architecture" yaml:"architecture"`
+	Location      string   `json:"location" yaml:"location"`
+	Mirrors       []string `json:"mirrors" yaml:"mirrors"`
+	ChecksumType  string   `json:"checksumType" yaml:"checksumType"`
+	Checksum      string   `json:"checksum" yaml:"checksum"`
+	Size          int64    `json:"size" yaml:"size"`
+	InstalledSize int64    `json:"installedSize" yaml:"installedSize"`
+	// BuildTime is formatted as RFC 3339.
+	BuildTime     string `json:"buildTime" yaml:"buildTime"`
+	License       string `json:"license" yaml:"license"`
+	Vendor        string `json:"vendor" yaml:"vendor"`
+	SourcePackage string `json:"sourcePackage" yaml:"sourcePackage"`
+	Summary       string `json:"summary" yaml:"summary"`
+	Repository    string `json:"repository" yaml:"repository"`
+}
+
+// NewResult returns the representation of the package.
+func NewResult(p *Package) *Result {
+	r := &Result{
+		Query:         p.query,
+		Name:          p.name,
+		Epoch:         p.epoch,
+		Version:       p.version,
+		Release:       p.release,
+		Architecture:  p.architecture,
+		Location:      p.location,
+		Mirrors:       append([]string{}, p.mirrors...),
+		ChecksumType:  p.checksum.Type,
+		Checksum:      p.checksum.Value,
+		Size:          p.size,
+		InstalledSize: p.installedSize,
+		License:       p.license,
+		Vendor:        p.vendor,
+		SourcePackage: p.sourcePackage,
+		Summary:       p.summary,
+		Repository:    p.repository,
+	}
+	if !p.buildTime.IsZero() {
+		r.BuildTime = p.buildTime.UTC().Format(time.RFC3339)
+	}
+
+	return r
+}
+
+// Fields returns the values of the result in the order of the ResultColumns.
+// The mirrors are separated by spaces.
+func (r *Result) Fields() []string {
+	return []string{
+		r.Query,
+		r.Name,
+		r.Epoch,
+		r.Version,
+		r.Release,
+		r.Architecture,
+		r.Location,
+		strings.Join(r.Mirrors, " "),
+		r.ChecksumType,
+		r.Checksum,
+		strconv.FormatInt(r.Size, 10),
+		strconv.FormatInt(r.InstalledSize, 10),
+		r.BuildTime,
+		r.License,
+		r.Vendor,
+		r.SourcePackage,
+		r.Summary,
+		r.Repository,
+	}
+}
+
+// Writer is a pipeline sink that writes the packages in a format.
+type Writer struct {
+	format Format
+	output io.Writer
+	logger *log.Logger
+}
+
+type WriterOption func(w *Writer)
+
+func WithWriterFormat(format Format) WriterOption {
+	return func(w *Writer) {
+		w.format = format
+	}
+}
+
+// WithWriterOutput sets where the packages are written, which is the standard output by default.
+func WithWriterOutput(output io.Writer) WriterOption {
+	return func(w *Writer) {
+		w.output = output
+	}
+}
+
+func WithWriterLogger(logger *log.Logger) WriterOption {
+	return func(w *Writer) {
+		w.logger = logger
+	}
+}
+
+func NewWriter(o ...WriterOption) *Writer {
+	w := &Writer{format: FormatNDJSON, output: os.Stdout, logger: log.New()}
+	for _, f := range o {
+		f(w)
+	}
+
+	return w
+}
+
+func (w *Writer) validate() error {
+	for _, v := range Formats {
+		if w.format == v {
+			return nil
+		}
+	}
+
+	return errors.Wrap(ErrFormatNotSupported, string(w.format))
+}
+
+// Run writes the packages received from the source until it is closed,
+// or the context is done. In the latter case, the packages written so far
+// are flushed, so that the output is complete, and the context error is returned.
+func (w *Writer) Run(ctx context.Context, source chan *Package) error {
+	// The source is drained when the writer stops before it is closed.
+	defer Drain(source)
+
+	if err := w.validate(); err != nil {
+		w.logger.WithError(err).Error("validate")
+		return err
+	}
+
+	e := w.newEncoder()
+	if err := e.begin(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := e.end(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case p, ok := <-source:
+			if !ok {
+				return e.end()
+			}
+			w.logger.WithField("package", p.name).Debug("write")
+			if err := e.encode(NewResult(p)); err != nil {
+				return errors.Wrap(err, "error writing the package")
+			}
+		}
+	}
+}
+
+// encoder writes the results in a format.
+type encoder interface {
+	begin() error
+	encode(r *Result) error
+	end() error
+}
+
+func (w *Writer) newEncoder() encoder {
+	switch w.format {
+	case FormatJSON:
+		return &jsonArrayEncoder{output: w.output}
+	case FormatYAML:
+		return &yamlEncoder{encoder: yaml.NewEncoder(w.output)}
+	case FormatCSV:
+		return &csvEncoder{writer: csv.NewWriter(w.output)}
+	case FormatTable:
+		return &tableEncoder{writer: tabwriter.NewWriter(w.output, 0, 0, 2, ' ', 0)}
+	default:
+		return &ndjsonEncoder{encoder: json.NewEncoder(w.output)}
+	}
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin() error           { return nil }
+func (e *ndjsonEncoder) encode(r *Result) error { return e.encoder.Encode(r) }
+func (e *ndjsonEncoder) end() error             { return nil }
+
+// jsonArrayEncoder streams the elements of the array as they are received,
+// so that the results do not need to be kept in memory.
+type jsonArrayEncoder struct {
+	output io.Writer
+	count  int
+}
+
+func (e *jsonArrayEncoder) begin() error {
+	_, err := io.WriteString(e.output, "[")
+
+	return err
+}
+
+func (e *jsonArrayEncoder) encode(r *Result) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "\n"
+	}
+	e.count++
+
+	_, err = fmt.Fprintf(e.output, "%s%s", sep, b)
+
+	return err
+}
+
+func (e *jsonArrayEncoder) end() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(e.output, end)
+
+	return err
+}
+
+type yamlEncoder struct {
+	encoder *yaml.Encoder
+}
+
+func (e *yamlEncoder) begin() error           { return nil }
+func (e *yamlEncoder) encode(r *Result) error { return e.encoder.Encode(r) }
+func (e *yamlEncoder) end() error             { return e.encoder.Close() }
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvEncoder) begin() error { return e.writer.Write(ResultColumns) }
+
+func (e *csvEncoder) encode(r *Result) error {
+	if err := e.writer.Write(r.Fields()); err != nil {
+		return err
+	}
+	// The records are flushed as they are written, to stream them.
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) end() error {
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
+
+// tableEncoder aligns the columns when all the rows are written,
+// so the table is written when the source is closed.
+type tableEncoder struct {
+	writer *tabwriter.Writer
+}
+
+func (e *tableEncoder) begin() error {
+	_, err := fmt.Fprintln(e.writer, strings.ToUpper(strings.Join(tableColumns, "\t")))
+
+	return err
+}
+
+func (e *tableEncoder) encode(r *Result) error {
+	_, err := fmt.Fprintln(e.writer, strings.Join([]string{
+		r.Query,
+		r.Name,
+		r.Version,
+		r.Architecture,
+		r.Repository,
+		r.Location,
+	}, "\t"))
+
+	return err
+}
+
+func (e *tableEncoder) end() error { return e.writer.Flush() }
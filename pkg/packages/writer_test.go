@@ -0,0 +1,175 @@
+//go:build all_tests || all_unit_tests || (unit_tests && writer)
+
+package packages_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	"github.com/maxgio92/linux-packages/pkg/packages"
+)
+
+var _ = Describe("Writer", func() {
+	var (
+		ctx  = context.Background()
+		repo = "https://mirrors.edge.kernel.org/centos/9-stream/AppStream/x86_64/os/"
+	)
+
+	newPackage := func(rel string) *packages.Package {
+		return packages.NewPackage(
+			packages.WithName("kernel-devel"),
+			packages.WithQuery("kernel-devel"),
+			packages.WithArchitecture("x86_64"),
+			packages.WithEpoch("0"),
+			packages.WithVersion("5.14.0+"+rel),
+			packages.WithRelease(rel),
+			packages.WithChecksum("sha256", "a"),
+			packages.WithSize(1024),
+			packages.WithBuildTime(time.Unix(1700000000, 0)),
+			packages.WithRepository(repo),
+			packages.WithLocation(repo+"Packages/kernel-devel-5.14.0-"+rel+".x86_64.rpm"),
+		)
+	}
+
+	write := func(format packages.Format, source ...*packages.Package) (string, error) {
+		sourceCh := make(chan *packages.Package)
+		go func() {
+			for _, v := range source {
+				sourceCh <- v
+			}
+			close(sourceCh)
+		}()
+
+		out := new(bytes.Buffer)
+		err := packages.NewWriter(
+			packages.WithWriterFormat(format),
+			packages.WithWriterOutput(out),
+		).Run(ctx, sourceCh)
+
+		return out.String(), err
+	}
+
+	It("Should write a JSON object per line", func() {
+		out, err := write(packages.FormatNDJSON, newPackage("362.el9"), newPackage("284.el9"))
+		Expect(err).To(BeNil())
+
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		Expect(lines).To(HaveLen(2))
+
+		r := new(packages.Result)
+		Expect(json.Unmarshal([]byte(lines[0]), r)).To(Succeed())
+		Expect(r.Name).To(Equal("kernel-devel"))
+		Expect(r.Release).To(Equal("362.el9"))
+		Expect(r.Checksum).To(Equal("a"))
+		Expect(r.Size).To(Equal(int64(1024)))
+		Expect(r.BuildTime).To(Equal("2023-11-14T22:13:20Z"))
+		Expect(r.Mirrors).To(BeEmpty())
+	})
+
+	It("Should write the fields of the schema even when empty", func() {
+		out, err := write(packages.FormatNDJSON, packages.NewPackage(packages.WithName("kernel-devel")))
+		Expect(err).To(BeNil())
+
+		fields := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(out), &fields)).To(Succeed())
+		Expect(fields).To(HaveLen(len(packages.ResultColumns)))
+		for _, v := range packages.ResultColumns {
+			Expect(fields).To(HaveKey(v))
+		}
+	})
+
+	It("Should write a JSON array", func() {
+		out, err := write(packages.FormatJSON, newPackage("362.el9"), newPackage("284.el9"))
+		Expect(err).To(BeNil())
+
+		var results []*packages.Result
+		Expect(json.Unmarshal([]byte(out), &results)).To(Succeed())
+		Expect(results).To(HaveLen(2))
+	})
+
+	It("Should write an empty JSON array without packages", func() {
+		out, err := write(packages.FormatJSON)
+		Expect(err).To(BeNil())
+		Expect(out).To(Equal("[]\n"))
+	})
+
+	It("Should write a YAML document per package", func() {
+		out, err := write(packages.FormatYAML, newPackage("362.el9"), newPackage("284.el9"))
+		Expect(err).To(BeNil())
+
+		decoder := yaml.NewDecoder(strings.NewReader(out))
+		for _, rel := range []string{"362.el9", "284.el9"} {
+			r := new(packages.Result)
+			Expect(decoder.Decode(r)).To(Succeed())
+			Expect(r.Release).To(Equal(rel))
+		}
+	})
+
+	It("Should write a CSV record per package after the header", func() {
+		out, err := write(packages.FormatCSV, newPackage("362.el9"))
+		Expect(err).To(BeNil())
+
+		records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+		Expect(err).To(BeNil())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0]).To(Equal(packages.ResultColumns))
+		Expect(records[1][1]).To(Equal("kernel-devel"))
+	})
+
+	It("Should write a table row per package after the header", func() {
+		out, err := write(packages.FormatTable, newPackage("362.el9"))
+		Expect(err).To(BeNil())
+
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(strings.Fields(lines[0])).To(Equal([]string{"QUERY", "NAME", "VERSION", "ARCHITECTURE", "REPOSITORY", "LOCATION"}))
+		Expect(lines[1]).To(ContainSubstring("5.14.0+362.el9"))
+	})
+
+	It("Should write the query of the package in the first column of the table", func() {
+		p := packages.NewPackage(
+			packages.WithName("kernel-devel"),
+			packages.WithQuery("kernel-*-devel"),
+			packages.WithLocation(repo+"Packages/kernel-devel-5.14.0-362.el9.x86_64.rpm"),
+		)
+		out, err := write(packages.FormatTable, p)
+		Expect(err).To(BeNil())
+
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(strings.Fields(lines[1])[:2]).To(Equal([]string{"kernel-*-devel", "kernel-devel"}))
+	})
+
+	It("Should flush the packages written when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		sourceCh := make(chan *packages.Package)
+		go func() {
+			sourceCh <- newPackage("362.el9")
+			cancel()
+		}()
+
+		out := new(bytes.Buffer)
+		err := packages.NewWriter(
+			packages.WithWriterFormat(packages.FormatJSON),
+			packages.WithWriterOutput(out),
+		).Run(ctx, sourceCh)
+		Expect(err).To(MatchError(context.Canceled))
+
+		var results []*packages.Result
+		Expect(json.Unmarshal(out.Bytes(), &results)).To(Succeed())
+		Expect(results).To(HaveLen(1))
+	})
+
+	It("Should fail on a format not supported", func() {
+		_, err := write(packages.Format("xml"))
+		Expect(err).To(MatchError(packages.ErrFormatNotSupported))
+	})
+})